@@ -33,6 +33,12 @@ func (s *BidirectionalStream) StreamID() uint64 {
 	return s.s.StreamID()
 }
 
+// CloseWrite signals the peer that no more data will be written.
+// Reading from the stream remains possible.
+func (s *BidirectionalStream) CloseWrite() error {
+	return s.s.CloseWrite()
+}
+
 // SetDeadline sets read and write deadlines associated with the stream. A zero value for t means Read and Write will not timeout.
 func (s *BidirectionalStream) SetDeadline(t time.Time) error {
 	return s.s.SetDeadline(t)
@@ -0,0 +1,37 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrAcceptTimeout is returned by AcceptStream and AcceptUniStream when no
+// stream arrived within the window set by AcceptStreamDeadline. The session
+// is not affected and accepting can continue.
+var ErrAcceptTimeout = errors.New("quic: no stream accepted before the deadline")
+
+// AcceptStreamDeadline sets how long AcceptStream and AcceptUniStream block
+// waiting for a stream before returning ErrAcceptTimeout, allowing an
+// accept loop to do periodic work in between. Zero, the default, waits
+// until a stream arrives or the session is closed.
+func (s *Session) AcceptStreamDeadline(d time.Duration) {
+	s.acceptDeadline.Store(int64(d))
+}
+
+// acceptContext returns the context bounding a single accept call.
+func (s *Session) acceptContext() (context.Context, context.CancelFunc) {
+	d := time.Duration(s.acceptDeadline.Load())
+	if d <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), d)
+}
+
+// acceptError converts the error of an accept call made with ctx.
+func acceptError(ctx context.Context, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		return ErrAcceptTimeout
+	}
+	return err
+}
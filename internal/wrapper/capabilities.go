@@ -0,0 +1,46 @@
+package wrapper
+
+import (
+	"os"
+	"strconv"
+	"sync"
+)
+
+// SocketCapabilities reports the socket optimizations quic-go uses on this
+// system. They apply to sockets created by the wrapper and to other
+// *net.UDPConn sockets; sockets of other types never use the
+// optimizations.
+type SocketCapabilities struct {
+	// GSO reports whether UDP generic segmentation offload is supported
+	// by the kernel. Sending with GSO may still fail on interfaces that
+	// lack it, in which case quic-go falls back silently.
+	GSO bool
+	// ECN reports whether ECN marks are sent and read.
+	ECN bool
+	// BatchIO reports whether several packets are read with a single
+	// system call.
+	BatchIO bool
+}
+
+var (
+	capabilitiesOnce sync.Once
+	capabilities     SocketCapabilities
+)
+
+// Capabilities returns the socket optimizations detected on this
+// system, following the checks quic-go makes when it wraps a socket,
+// including the QUIC_GO_DISABLE_GSO and QUIC_GO_DISABLE_ECN environment
+// variables. The detection runs once.
+func Capabilities() SocketCapabilities {
+	capabilitiesOnce.Do(func() {
+		capabilities = detectCapabilities()
+	})
+	return capabilities
+}
+
+// disabledUsingEnv reports whether quic-go is told to not use a feature by
+// the environment variable name.
+func disabledUsingEnv(name string) bool {
+	disabled, err := strconv.ParseBool(os.Getenv(name))
+	return err == nil && disabled
+}
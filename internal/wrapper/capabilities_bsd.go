@@ -0,0 +1,12 @@
+//go:build darwin || freebsd
+
+package wrapper
+
+import "runtime"
+
+func detectCapabilities() SocketCapabilities {
+	return SocketCapabilities{
+		ECN:     !disabledUsingEnv("QUIC_GO_DISABLE_ECN"),
+		BatchIO: runtime.GOOS == "freebsd",
+	}
+}
@@ -0,0 +1,62 @@
+//go:build linux
+
+package wrapper
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func detectCapabilities() SocketCapabilities {
+	modern := kernelVersionMajor() >= 5
+	return SocketCapabilities{
+		GSO:     modern && !disabledUsingEnv("QUIC_GO_DISABLE_GSO") && probeGSO(),
+		ECN:     modern && !disabledUsingEnv("QUIC_GO_DISABLE_ECN"),
+		BatchIO: true,
+	}
+}
+
+// probeGSO checks for UDP_SEGMENT support on a temporary socket.
+func probeGSO() bool {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return false
+	}
+	defer func() { _ = conn.Close() }()
+
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var serr error
+	if err := rawConn.Control(func(fd uintptr) {
+		_, serr = unix.GetsockoptInt(int(fd), unix.IPPROTO_UDP, unix.UDP_SEGMENT)
+	}); err != nil {
+		return false
+	}
+	return serr == nil
+}
+
+func kernelVersionMajor() int {
+	var uname syscall.Utsname
+	if err := syscall.Uname(&uname); err != nil {
+		return 0
+	}
+	var release strings.Builder
+	for _, c := range uname.Release {
+		if c == 0 {
+			break
+		}
+		release.WriteByte(byte(c))
+	}
+	major, _, _ := strings.Cut(release.String(), ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+	return n
+}
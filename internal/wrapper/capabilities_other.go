@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !freebsd
+
+package wrapper
+
+func detectCapabilities() SocketCapabilities {
+	return SocketCapabilities{}
+}
@@ -0,0 +1,110 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCertificate(t *testing.T, key crypto.Signer) tls.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	assert.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func newDualCertificates(t *testing.T) (ecdsaCert, rsaCert tls.Certificate) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	return newTestCertificate(t, ecdsaKey), newTestCertificate(t, rsaKey)
+}
+
+func TestConfig_DualCertificates_Selection(t *testing.T) {
+	ecdsaCert, rsaCert := newDualCertificates(t)
+	tlsConfig := getTLSConfig(&Config{Certificates: []tls.Certificate{ecdsaCert, rsaCert}})
+
+	// crypto/tls presents the first certificate the ClientHello supports
+	selected := func(schemes ...tls.SignatureScheme) []byte {
+		hello := &tls.ClientHelloInfo{
+			SignatureSchemes:  schemes,
+			SupportedVersions: []uint16{tls.VersionTLS13},
+			SupportedCurves:   []tls.CurveID{tls.X25519, tls.CurveP256},
+		}
+		for _, cert := range tlsConfig.Certificates {
+			if hello.SupportsCertificate(&cert) == nil {
+				return cert.Certificate[0]
+			}
+		}
+		return nil
+	}
+
+	for name, tc := range map[string]struct {
+		schemes []tls.SignatureScheme
+		want    tls.Certificate
+	}{
+		"PrefersECDSA": {
+			schemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256, tls.PSSWithSHA256},
+			want:    ecdsaCert,
+		},
+		"OnlyRSA": {
+			schemes: []tls.SignatureScheme{tls.PSSWithSHA256},
+			want:    rsaCert,
+		},
+		"OnlyECDSA": {
+			schemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+			want:    ecdsaCert,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want.Certificate[0], selected(tc.schemes...))
+		})
+	}
+}
+
+func TestConfig_DualCertificates_Handshake(t *testing.T) {
+	ecdsaCert, rsaCert := newDualCertificates(t)
+
+	for name, tc := range map[string]struct {
+		certificates []tls.Certificate
+		want         x509.PublicKeyAlgorithm
+	}{
+		"RSAFirst":   {[]tls.Certificate{rsaCert, ecdsaCert}, x509.RSA},
+		"ECDSAFirst": {[]tls.Certificate{ecdsaCert, rsaCert}, x509.ECDSA},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			serverConfig := &Config{Certificates: tc.certificates, SkipVerify: true}
+			_, client := newTestSessions(t, serverConfig, newTestConfig(t))
+
+			certs := client.GetRemoteCertificates()
+			if assert.NotEmpty(t, certs) {
+				assert.Equal(t, tc.want, certs[0].PublicKeyAlgorithm)
+			}
+		})
+	}
+}
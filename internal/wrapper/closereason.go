@@ -0,0 +1,99 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"unicode/utf8"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// maxCloseReasonLength bounds the reason written by CloseStructured, so
+// the CONNECTION_CLOSE frame fits into the smallest packet QUIC allows.
+const maxCloseReasonLength = 1000
+
+// CloseTruncatedKey is set in the details of CloseStructured if they had to
+// be shortened to fit into the close reason.
+const CloseTruncatedKey = "truncated"
+
+// CloseStructured closes the session like CloseWithError, encoding details
+// as a JSON object in the reason of the close, for the peer to parse with
+// CloseDetails.
+//
+// The reason is limited to 1000 bytes to fit into a single packet. Longer
+// details are shortened: entries are kept in the order of their keys, the
+// first one that does not fit has its value cut, the following ones are
+// dropped, and CloseTruncatedKey is set to "true".
+func (s *Session) CloseStructured(code uint16, details map[string]string) error {
+	return s.s.CloseWithError(quic.ApplicationErrorCode(code), encodeCloseReason(details))
+}
+
+// CloseDetails returns the details of a session closed by the peer with
+// CloseStructured, taken from the session's close error, e.g. the error
+// returned by AcceptStream. It reports false if err is not an application
+// close or its reason is not a JSON object.
+func CloseDetails(err error) (map[string]string, bool) {
+	var appErr *quic.ApplicationError
+	if !errors.As(err, &appErr) {
+		return nil, false
+	}
+	var details map[string]string
+	if json.Unmarshal([]byte(appErr.ErrorMessage), &details) != nil || details == nil {
+		return nil, false
+	}
+	return details, true
+}
+
+func encodeCloseReason(details map[string]string) string {
+	if details == nil {
+		details = map[string]string{}
+	}
+	if reason := marshalCloseReason(details); len(reason) <= maxCloseReasonLength {
+		return reason
+	}
+
+	keys := make([]string, 0, len(details))
+	for k := range details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kept := map[string]string{CloseTruncatedKey: "true"}
+	for _, k := range keys {
+		v := details[k]
+		kept[k] = v
+		if len(marshalCloseReason(kept)) <= maxCloseReasonLength {
+			continue
+		}
+		// keep the longest prefix of v that fits, if any
+		n := sort.Search(len(v)+1, func(n int) bool {
+			kept[k] = truncateUTF8(v, n)
+			return len(marshalCloseReason(kept)) > maxCloseReasonLength
+		}) - 1
+		if n < 0 {
+			delete(kept, k)
+		} else {
+			kept[k] = truncateUTF8(v, n)
+		}
+		break
+	}
+	return marshalCloseReason(kept)
+}
+
+func marshalCloseReason(details map[string]string) string {
+	data, _ := json.Marshal(details) // a map of strings always marshals
+	return string(data)
+}
+
+// truncateUTF8 returns the first n bytes of s, shortened further so as not
+// to split a character.
+func truncateUTF8(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,173 @@
+package wrapper
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Compression is a compression algorithm of a CompressedStream.
+type Compression byte
+
+// Supported compression algorithms. zstd is not included to keep the
+// wrapper free of dependencies beyond the standard library.
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionDeflate
+)
+
+var errUnknownCompression = errors.New("quic: unknown compression algorithm")
+
+// CompressedReadWriter compresses the data written to a stream and
+// decompresses the data read from it.
+type CompressedReadWriter struct {
+	s    *Stream
+	algo Compression
+
+	writeLock sync.Mutex
+	started   bool // the algorithm prefix was written
+	w         io.WriteCloser
+
+	readLock sync.Mutex
+	r        io.Reader
+}
+
+// CompressedStream wraps s to compress written data with algo. The
+// algorithm is announced in a one byte prefix before the data, so the
+// reading side picks the matching decompressor on its own and both
+// directions may use different algorithms.
+//
+// Compression is opt-in and happens in the application layer, independent
+// of QUIC: both peers must wrap the stream, and it must be wrapped before
+// any data is exchanged on it. Flush pushes out the data compressed so far
+// at some cost in compression ratio.
+func CompressedStream(s *Stream, algo Compression) *CompressedReadWriter {
+	return &CompressedReadWriter{s: s, algo: algo}
+}
+
+// Stream returns the underlying stream.
+func (c *CompressedReadWriter) Stream() *Stream {
+	return c.s
+}
+
+// Write compresses p and writes it to the stream.
+func (c *CompressedReadWriter) Write(p []byte) (int, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if err := c.start(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
+
+// Flush writes all data compressed so far to the stream.
+func (c *CompressedReadWriter) Flush() error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if err := c.start(); err != nil {
+		return err
+	}
+	if f, ok := c.w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	return c.s.Flush()
+}
+
+// Close finishes the compressed data and the write side of the stream.
+// Reading remains possible.
+func (c *CompressedReadWriter) Close() error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if err := c.start(); err != nil {
+		return err
+	}
+	if err := c.w.Close(); err != nil {
+		return err
+	}
+	return c.s.CloseWrite()
+}
+
+// start writes the algorithm prefix and sets up the compressor.
+// The write lock must be held.
+func (c *CompressedReadWriter) start() error {
+	if c.started {
+		return nil
+	}
+	var w io.WriteCloser
+	switch c.algo {
+	case CompressionNone:
+		w = nopWriteCloser{streamWriter{c.s}}
+	case CompressionGzip:
+		w = gzip.NewWriter(streamWriter{c.s})
+	case CompressionDeflate:
+		fw, err := flate.NewWriter(streamWriter{c.s}, flate.DefaultCompression)
+		if err != nil {
+			return err
+		}
+		w = fw
+	default:
+		return errUnknownCompression
+	}
+	if _, err := c.s.write([]byte{byte(c.algo)}); err != nil {
+		return err
+	}
+	c.started = true
+	c.w = w
+	return nil
+}
+
+// Read reads and decompresses data from the stream.
+func (c *CompressedReadWriter) Read(p []byte) (int, error) {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+	if c.r == nil {
+		r, err := c.newReader()
+		if err != nil {
+			return 0, err
+		}
+		c.r = r
+	}
+	return c.r.Read(p)
+}
+
+// newReader reads the peer's algorithm prefix and returns the matching
+// decompressor.
+func (c *CompressedReadWriter) newReader() (io.Reader, error) {
+	var prefix [1]byte
+	if _, err := io.ReadFull(c.s, prefix[:]); err != nil {
+		return nil, err
+	}
+	switch Compression(prefix[0]) {
+	case CompressionNone:
+		return c.s, nil
+	case CompressionGzip:
+		return gzip.NewReader(c.s)
+	case CompressionDeflate:
+		return flate.NewReader(c.s), nil
+	default:
+		c.s.cancel(errorCodeCanceled)
+		return nil, errUnknownCompression
+	}
+}
+
+// streamWriter adapts Stream to io.Writer.
+type streamWriter struct {
+	s *Stream
+}
+
+func (w streamWriter) Write(p []byte) (int, error) {
+	return w.s.write(p)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,81 @@
+package wrapper
+
+import (
+	"context"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// SendDatagram sends p as an unreliable QUIC datagram. Both endpoints
+// must enable datagrams, see Config.EnableDatagrams. A payload larger than
+// fits into a single packet is rejected with a *quic.DatagramTooLargeError
+// telling the current maximum, which depends on the path MTU. quic-go
+// queues up to 32 datagrams, SendDatagram blocks while the queue is full.
+func (s *Session) SendDatagram(p []byte) error {
+	return s.sendDatagram(nil, p)
+}
+
+// ReceiveDatagram returns the next datagram sent by the peer. An empty
+// datagram, which RFC 9221 allows, is returned as an empty, non-nil slice
+// and can be sent with an empty or nil p to SendDatagram. quic-go
+// queues up to 128 received datagrams and drops further ones until they
+// are read. It must not be used together with Subscribe, which consumes
+// all datagrams of the session.
+func (s *Session) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	p, err := s.receiveDatagram(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.totals.addReceived(len(p))
+	return p, nil
+}
+
+// sendDatagram sends p as a datagram after header, and after the type
+// prefix if receipts are enabled. The maximum of a *quic.DatagramTooLargeError
+// is reduced by the prefix and header.
+func (s *Session) sendDatagram(header, p []byte) error {
+	var prefix []byte
+	if s.receipts != nil {
+		prefix = []byte{datagramPlain}
+	}
+	if err := s.sendFramedDatagram(s.s.Context(), append(prefix, header...), p); err != nil {
+		return err
+	}
+	s.totals.addSent(len(p))
+	return nil
+}
+
+// sendFramedDatagram sends header and p in a single datagram. A blocking
+// rate limit waits until ctx is done.
+func (s *Session) sendFramedDatagram(ctx context.Context, header, p []byte) error {
+	if err := s.limiter.admit(ctx, len(header)+len(p)); err != nil {
+		return err
+	}
+	if len(header) == 0 {
+		return s.s.SendDatagram(p)
+	}
+	err := s.s.SendDatagram(append(header[:len(header):len(header)], p...))
+	if tooLarge, ok := err.(*quic.DatagramTooLargeError); ok {
+		return &quic.DatagramTooLargeError{MaxDatagramPayloadSize: max(tooLarge.MaxDatagramPayloadSize-int64(len(header)), 0)}
+	}
+	return err
+}
+
+// receiveDatagram returns the payload of the next datagram, never nil.
+func (s *Session) receiveDatagram(ctx context.Context) ([]byte, error) {
+	if s.receipts == nil {
+		p, err := s.s.ReceiveDatagram(ctx)
+		if err == nil && p == nil {
+			p = []byte{}
+		}
+		return p, err
+	}
+	select {
+	case p := <-s.receipts.queue:
+		return p, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.s.Context().Done():
+		return nil, context.Cause(s.s.Context())
+	}
+}
@@ -0,0 +1,115 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_ZeroLengthDatagram(t *testing.T) {
+	testCases := []struct {
+		name     string
+		receipts bool
+	}{
+		{"Plain", false},
+		{"WithReceipts", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			serverConfig, clientConfig := newTestConfig(t), newTestConfig(t)
+			serverConfig.EnableDatagrams, clientConfig.EnableDatagrams = true, true
+			serverConfig.DatagramReceipts, clientConfig.DatagramReceipts = tc.receipts, tc.receipts
+			server, client := newTestSessions(t, serverConfig, clientConfig)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			assert.NoError(t, client.SendDatagram([]byte{}))
+			p, err := server.ReceiveDatagram(ctx)
+			assert.NoError(t, err)
+			assert.NotNil(t, p)
+			assert.Empty(t, p)
+		})
+	}
+}
+
+func TestSession_ZeroLengthMessage(t *testing.T) {
+	serverConfig, clientConfig := newTestConfig(t), newTestConfig(t)
+	serverConfig.EnableDatagrams, clientConfig.EnableDatagrams = true, true
+	server, client := newTestSessions(t, serverConfig, clientConfig)
+
+	msgs, unsubscribe := server.Subscribe("signal")
+	defer unsubscribe()
+
+	// Datagrams are unreliable, so keep publishing until one arrives.
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-msgs:
+			assert.NotNil(t, msg)
+			assert.Empty(t, msg)
+			return
+		case <-ticker.C:
+			assert.NoError(t, client.Publish("signal", nil))
+		case <-timeout:
+			t.Fatal("no message received")
+		}
+	}
+}
+
+func TestSession_DatagramRateLimit(t *testing.T) {
+	const rate = 5 // datagrams per second, also the burst
+
+	testCases := []struct {
+		name  string
+		block bool
+	}{
+		{"Drop", false},
+		{"Block", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			serverConfig, clientConfig := newTestConfig(t), newTestConfig(t)
+			serverConfig.DatagramReceipts, clientConfig.DatagramReceipts = true, true
+			clientConfig.DatagramPacketRate = rate
+			clientConfig.DatagramRateBlock = tc.block
+			_, client := newTestSessions(t, serverConfig, clientConfig)
+
+			for i := 0; i < rate; i++ {
+				assert.NoError(t, client.SendDatagram([]byte("burst")))
+			}
+
+			if !tc.block {
+				assert.ErrorIs(t, client.SendDatagram([]byte("over")), ErrDatagramRateLimited)
+				assert.Equal(t, uint64(1), client.DroppedDatagrams())
+				time.Sleep(2 * time.Second / rate)
+				assert.NoError(t, client.SendDatagram([]byte("refilled")))
+				return
+			}
+
+			// A receipt datagram waiting for the limit gives up with its ctx.
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			assert.ErrorIs(t, client.SendDatagramWithReceipt(ctx, []byte("over")), context.DeadlineExceeded)
+			assert.Less(t, time.Since(start), time.Second/(2*rate))
+
+			start = time.Now()
+			assert.NoError(t, client.SendDatagram([]byte("refilled")))
+			assert.Greater(t, time.Since(start), time.Second/(2*rate))
+			assert.Equal(t, uint64(1), client.ThrottledDatagrams())
+			assert.Zero(t, client.DroppedDatagrams())
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDatagramRateLimited is returned when a datagram is dropped because
+// it exceeds Config.DatagramByteRate or Config.DatagramPacketRate.
+var ErrDatagramRateLimited = errors.New("quic: datagram rate limit exceeded")
+
+// datagramLimiter applies the datagram rate limits of a session.
+type datagramLimiter struct {
+	lock    sync.Mutex // guards both buckets, used without their own locks
+	bytes   *tokenBucket
+	packets *tokenBucket
+	block   bool
+
+	throttled atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// newDatagramLimiter returns the limiter of config's datagram rates, or
+// nil if there are none. Bytes count the whole datagram payload, including
+// the headers of Publish and DatagramReceipts; stream data is not
+// affected.
+func newDatagramLimiter(config *Config) *datagramLimiter {
+	if config.DatagramByteRate <= 0 && config.DatagramPacketRate <= 0 {
+		return nil
+	}
+	l := &datagramLimiter{block: config.DatagramRateBlock}
+	if config.DatagramByteRate > 0 {
+		l.bytes = newTokenBucket(config.DatagramByteRate)
+	}
+	if config.DatagramPacketRate > 0 {
+		l.packets = newTokenBucket(config.DatagramPacketRate)
+	}
+	return l
+}
+
+// admit takes the tokens for a datagram of n bytes, waiting for them
+// until ctx is done if the limiter blocks.
+func (l *datagramLimiter) admit(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	var timer *time.Timer
+	for {
+		wait := l.take(n)
+		if wait == 0 {
+			if timer != nil {
+				l.throttled.Add(1)
+			}
+			return nil
+		}
+		if !l.block {
+			l.dropped.Add(1)
+			return ErrDatagramRateLimited
+		}
+
+		if timer == nil {
+			timer = time.NewTimer(wait)
+			defer timer.Stop()
+		} else {
+			timer.Reset(wait)
+		}
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
+
+// take removes the tokens of a datagram of n bytes if both buckets have
+// enough of them, and returns the time to wait for them otherwise.
+func (l *datagramLimiter) take(n int) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	buckets := [...]struct {
+		b    *tokenBucket
+		cost float64
+	}{{l.bytes, float64(n)}, {l.packets, 1}}
+
+	var wait time.Duration
+	for _, c := range buckets {
+		if c.b != nil {
+			c.b.refill(now)
+			wait = max(wait, c.b.delay(c.cost))
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+	for _, c := range buckets {
+		if c.b != nil {
+			c.b.tokens -= c.cost
+		}
+	}
+	return 0
+}
+
+// ThrottledDatagrams returns the number of datagrams that were held back
+// by the rate limit before being sent, see Config.DatagramRateBlock.
+func (s *Session) ThrottledDatagrams() uint64 {
+	if s.limiter == nil {
+		return 0
+	}
+	return s.limiter.throttled.Load()
+}
+
+// DroppedDatagrams returns the number of datagrams that were dropped
+// because of the rate limit, see Config.DatagramByteRate.
+func (s *Session) DroppedDatagrams() uint64 {
+	if s.limiter == nil {
+		return 0
+	}
+	return s.limiter.dropped.Load()
+}
@@ -0,0 +1,58 @@
+package wrapper
+
+import (
+	"context"
+	"io"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// DemuxOptions configure RunDemux.
+type DemuxOptions struct {
+	// Workers is the number of streams handled at once, see ServeStreams.
+	// Zero selects one.
+	Workers int
+	// UnknownTypeCode is the error code streams of a type without handler
+	// are reset with.
+	UnknownTypeCode uint16
+}
+
+// RunDemux accepts incoming streams like ServeStreams and dispatches each
+// one by its first byte, the stream type, to the handler registered for
+// it in handlers. The handler reads the stream from right after the type
+// byte. Streams of a type without handler are reset in both directions
+// with opts.UnknownTypeCode, and streams finished or reset before sending
+// their type are canceled with error code 0.
+//
+// The type byte is read by the worker, so a peer that is slow to send it
+// only holds up that worker; once ctx is done, streams still waiting for
+// their type are canceled. RunDemux returns like ServeStreams.
+func (s *Session) RunDemux(ctx context.Context, handlers map[byte]func(*Stream), opts DemuxOptions) error {
+	return s.ServeStreams(ctx, func(str *Stream) {
+		typ, ok := readStreamType(ctx, str)
+		if !ok {
+			return
+		}
+		handler, ok := handlers[typ]
+		if !ok {
+			str.cancel(quic.StreamErrorCode(opts.UnknownTypeCode))
+			return
+		}
+		handler(str)
+	}, opts.Workers)
+}
+
+// readStreamType reads the type byte of str, canceling the stream if that
+// fails or ctx is done first.
+func readStreamType(ctx context.Context, str *Stream) (byte, bool) {
+	stop := context.AfterFunc(ctx, func() {
+		str.cancel(errorCodeCanceled)
+	})
+	var typ [1]byte
+	_, err := io.ReadFull(str, typ[:])
+	if !stop() || err != nil {
+		str.cancel(errorCodeCanceled)
+		return 0, false
+	}
+	return typ[0], true
+}
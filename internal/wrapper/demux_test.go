@@ -0,0 +1,68 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_RunDemux(t *testing.T) {
+	const unknownTypeCode = 9
+
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.RunDemux(ctx, map[byte]func(*Stream){
+			1: func(str *Stream) {
+				p, err := io.ReadAll(str)
+				if err == nil {
+					_, _ = str.WriteQuic(p, true)
+				}
+			},
+		}, DemuxOptions{Workers: 2, UnknownTypeCode: unknownTypeCode})
+	}()
+
+	roundTrip := func(typ byte) ([]byte, error) {
+		str, err := client.OpenStream()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := str.WriteQuic(append([]byte{typ}, "hello"...), true); err != nil {
+			return nil, err
+		}
+		return io.ReadAll(str)
+	}
+
+	resp, err := roundTrip(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(resp))
+
+	_, err = roundTrip(7)
+	var streamErr *quic.StreamError
+	if assert.ErrorAs(t, err, &streamErr) {
+		assert.Equal(t, quic.StreamErrorCode(unknownTypeCode), streamErr.ErrorCode)
+	}
+
+	// An unknown stream type does not affect later streams.
+	resp, err = roundTrip(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(resp))
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.True(t, errors.Is(err, context.Canceled), err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunDemux did not return")
+	}
+}
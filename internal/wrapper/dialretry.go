@@ -0,0 +1,108 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// Defaults of DialRetryOptions.
+const (
+	defaultDialAttempts = 3
+	dialAttemptTimeout  = 10 * time.Second // like Dial
+)
+
+// DialRetryOptions configure DialWithRetry.
+type DialRetryOptions struct {
+	// MaxAttempts is the number of dials made at most. Zero selects 3.
+	MaxAttempts int
+	// AttemptTimeout bounds each dial. Zero selects 10 seconds, like Dial.
+	AttemptTimeout time.Duration
+	// Retryable decides whether a failed dial is retried. Nil selects
+	// IsRetryableDialError.
+	Retryable func(error) bool
+}
+
+// DialWithRetry dials addr like DialContext, retrying failed dials that
+// opts.Retryable classifies as transient, with a backoff from 100ms
+// doubling up to 5 seconds. Fatal errors, like an invalid certificate, are
+// returned right away, as are the errors of the last attempt and the
+// context's error once ctx is done.
+func DialWithRetry(ctx context.Context, addr string, config *Config, opts DialRetryOptions) (*Session, error) {
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultDialAttempts
+	}
+	timeout := opts.AttemptTimeout
+	if timeout <= 0 {
+		timeout = dialAttemptTimeout
+	}
+	retryable := opts.Retryable
+	if retryable == nil {
+		retryable = IsRetryableDialError
+	}
+
+	var backoff time.Duration
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		s, err := DialContext(attemptCtx, addr, config)
+		cancel()
+		if err == nil {
+			return s, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= attempts || !retryable(err) {
+			return nil, err
+		}
+
+		backoff = min(max(2*backoff, minDialRetry), maxDialRetry)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// IsRetryableDialError reports whether a dial failed with err for a reason
+// that may be transient: a timeout, a stateless reset, a server refusing
+// connections for now, e.g. because of its handshake limits, or a network
+// that is unreachable or refuses the packets. Failures of the TLS
+// handshake, like an invalid certificate, version negotiation and
+// configuration errors are permanent, as are errors it does not know.
+func IsRetryableDialError(err error) bool {
+	var (
+		transportErr *quic.TransportError
+		handshakeErr *quic.HandshakeTimeoutError
+		idleErr      *quic.IdleTimeoutError
+		resetErr     *quic.StatelessResetError
+		dnsErr       *net.DNSError
+		netErr       net.Error
+	)
+	switch {
+	case errors.As(err, &transportErr):
+		return transportErr.ErrorCode == quic.ConnectionRefused
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &handshakeErr),
+		errors.As(err, &idleErr),
+		errors.As(err, &resetErr):
+		return true
+	case errors.As(err, &dnsErr):
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	case errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ENETUNREACH),
+		errors.Is(err, syscall.EHOSTUNREACH):
+		return true
+	case errors.As(err, &netErr):
+		return netErr.Timeout()
+	}
+	return false
+}
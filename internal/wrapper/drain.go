@@ -0,0 +1,175 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/logging"
+)
+
+var errDrainUnsupported = errors.New("quic: stream opened without StreamOptions.TrackAcks")
+
+// byteRange is the range [start, end) of a stream's data.
+type byteRange struct {
+	start, end logging.ByteCount
+}
+
+// sentStreamData is a piece of a watched stream's data sent in a packet.
+type sentStreamData struct {
+	acks *streamAcks
+	r    byteRange
+}
+
+// streamAcks collects the acknowledged data of a stream.
+type streamAcks struct {
+	id    logging.StreamID
+	acked []byteRange // sorted and merged
+	final logging.ByteCount
+	fin   bool // final is known
+}
+
+// ackedPrefix returns the number of bytes from the start of the stream for
+// which all data was acknowledged.
+func (a *streamAcks) ackedPrefix() logging.ByteCount {
+	if len(a.acked) == 0 || a.acked[0].start > 0 {
+		return 0
+	}
+	return a.acked[0].end
+}
+
+func (a *streamAcks) add(r byteRange) {
+	if r.end <= r.start {
+		return
+	}
+	i := sort.Search(len(a.acked), func(i int) bool { return a.acked[i].end >= r.start })
+	j := i
+	for j < len(a.acked) && a.acked[j].start <= r.end {
+		r.start = min(r.start, a.acked[j].start)
+		r.end = max(r.end, a.acked[j].end)
+		j++
+	}
+	a.acked = append(a.acked[:i], append([]byteRange{r}, a.acked[j:]...)...)
+}
+
+// watchStream starts collecting the acknowledgements of the data of str.
+// Collecting ends once all data including the FIN was acknowledged or the
+// stream was reset.
+func (t *tracer) watchStream(str *quic.SendStream) *streamAcks {
+	acks := &streamAcks{id: str.StreamID()}
+	context.AfterFunc(str.Context(), func() {
+		if !errors.Is(context.Cause(str.Context()), context.Canceled) {
+			t.unwatchStream(acks)
+		}
+	})
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.watched == nil {
+		t.watched = make(map[logging.StreamID]*streamAcks)
+		t.sentData = make(map[logging.PacketNumber][]sentStreamData)
+		t.acksChanged = make(chan struct{})
+	}
+	t.watched[acks.id] = acks
+	return acks
+}
+
+// unwatchStream stops collecting acknowledgements for acks.
+func (t *tracer) unwatchStream(acks *streamAcks) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.watched[acks.id] == acks {
+		delete(t.watched, acks.id)
+	}
+}
+
+// sentPacket records the data of watched streams in packet pn.
+func (t *tracer) sentPacket(pn logging.PacketNumber, frames []logging.Frame) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.watched) == 0 {
+		return
+	}
+	for _, f := range frames {
+		sf, ok := f.(*logging.StreamFrame)
+		if !ok {
+			continue
+		}
+		if acks, ok := t.watched[sf.StreamID]; ok {
+			if sf.Fin {
+				acks.final, acks.fin = sf.Offset+sf.Length, true
+			}
+			t.sentData[pn] = append(t.sentData[pn], sentStreamData{acks: acks, r: byteRange{sf.Offset, sf.Offset + sf.Length}})
+		}
+	}
+}
+
+// ackedPacket marks the data of watched streams in packet pn as acknowledged.
+// Lost packets are only forgotten, their data is sent again in new packets.
+func (t *tracer) ackedPacket(pn logging.PacketNumber, acked bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	data, ok := t.sentData[pn]
+	if !ok {
+		return
+	}
+	delete(t.sentData, pn)
+	if !acked {
+		return
+	}
+	for _, d := range data {
+		d.acks.add(d.r)
+		if d.acks.fin && d.acks.ackedPrefix() >= d.acks.final && t.watched[d.acks.id] == d.acks {
+			delete(t.watched, d.acks.id)
+		}
+	}
+	close(t.acksChanged)
+	t.acksChanged = make(chan struct{})
+}
+
+// WaitDrained flushes the stream and blocks until the peer acknowledged all
+// data written so far. It fails once ctx is done, the stream is reset or
+// the session ends, even after the stream was finished. Unlike finishing
+// the stream, which only tells the peer that no more data follows, this
+// confirms that the data was received by the peer's QUIC stack; it does not
+// tell whether the application read it.
+//
+// The stream has to be opened with StreamOptions.TrackAcks, otherwise
+// WaitDrained fails right away. Acknowledgements are taken from quic-go's
+// packet tracing, they are not part of its API. Data sent in 0-RTT packets
+// is not covered.
+func (s *WritableStream) WaitDrained(ctx context.Context) error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+	if s.acks == nil {
+		return errDrainUnsupported
+	}
+	target := logging.ByteCount(s.written.Load())
+
+	streamDone := s.s.Context().Done()
+	for {
+		s.tracer.lock.Lock()
+		acked := s.acks.ackedPrefix()
+		changed := s.tracer.acksChanged
+		s.tracer.lock.Unlock()
+		if acked >= target {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-streamDone:
+			if err := context.Cause(s.s.Context()); !errors.Is(err, context.Canceled) {
+				return err // reset or session closed
+			}
+			// finished normally, acknowledgements are still coming
+			streamDone = nil
+		case <-s.session.Done():
+			return context.Cause(s.session)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package wrapper
+
+import (
+	"errors"
+	"net"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+var (
+	errInvalidDSCP     = errors.New("quic: DSCP value must be between 0 and 63")
+	errDSCPUnsupported = errors.New("quic: the session's socket does not support setting DSCP")
+)
+
+// SetDSCP sets the Differentiated Services Code Point of the packets sent
+// by the session. It requires the session to run over a *net.UDPConn, either
+// created by Dial/Listen or passed to Client/Server.
+//
+// The marking applies to the socket, so on the server it affects every
+// session accepted by the same Listener. On Linux, quic-go sets the ECN bits
+// of each packet through a control message, which overrides the socket's
+// TOS byte; set QUIC_GO_DISABLE_ECN=true for the DSCP to take effect there.
+// Platforms without IP_TOS/IPV6_TCLASS support return an error.
+func (s *Session) SetDSCP(value int) error {
+	if value < 0 || value > 63 {
+		return errInvalidDSCP
+	}
+
+	conn := udpConn(s.packetConn())
+	if conn == nil {
+		return errDSCPUnsupported
+	}
+
+	tos := value << 2
+	if raddr, ok := s.s.RemoteAddr().(*net.UDPAddr); ok && raddr.IP.To4() == nil {
+		return ipv6.NewPacketConn(conn).SetTrafficClass(tos)
+	}
+	return ipv4.NewPacketConn(conn).SetTOS(tos)
+}
+
+// udpConn returns the *net.UDPConn backing pc, if any.
+func udpConn(pc net.PacketConn) *net.UDPConn {
+	switch c := pc.(type) {
+	case *net.UDPConn:
+		return c
+	case *fakePacketConn:
+		if u, ok := c.c.(*net.UDPConn); ok {
+			return u
+		}
+	}
+	return nil
+}
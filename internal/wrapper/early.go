@@ -0,0 +1,63 @@
+package wrapper
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/logging"
+)
+
+// quicListener is implemented by quic.Listener and quic.EarlyListener.
+type quicListener interface {
+	Accept(context.Context) (*quic.Conn, error)
+	Close() error
+	Addr() net.Addr
+}
+
+// listen creates the quic-go listener on pc, accepting sessions early if
+// enabled by Config.EarlyAccept.
+//
+// An early session is handed out once the server sent its part of the
+// handshake, before the client's Finished message. Until the handshake
+// completes, the client's certificate is not verified and the handshake
+// may still fail, so only data fit for any client must be sent; streams
+// opened by the client are accepted only afterwards. The client's address
+// is validated only if quic-go sends a Retry. Events of the streams opened
+// in the meantime may precede EventHandshakeComplete.
+func listen(pc net.PacketConn, tlsConfig *tls.Config, quicConfig *quic.Config, config *Config, tracers *tracerRegistry) (quicListener, error) {
+	tracers.recordOfferedProtocols(tlsConfig)
+	if config.MaxConcurrentHandshakes > 0 {
+		return listenTransport(pc, tlsConfig, quicConfig, config, tracers)
+	}
+	if config.EarlyAccept {
+		return quic.ListenEarly(pc, tlsConfig, quicConfig)
+	}
+	return quic.Listen(pc, tlsConfig, quicConfig)
+}
+
+// HandshakeComplete returns a channel that is closed once the handshake
+// completed. A listener with Config.EarlyAccept hands out sessions before
+// that, which can wait on it before sending data meant for an
+// authenticated client only.
+func (s *Session) HandshakeComplete() <-chan struct{} {
+	return s.s.HandshakeComplete()
+}
+
+// EarlyDataBytes returns the number of stream bytes the server received
+// as 0-RTT data on the session, counting every STREAM frame of an accepted
+// 0-RTT packet. It is zero for sessions that were not resumed with 0-RTT,
+// and on the client side, as the wrapper dials without 0-RTT.
+func (s *Session) EarlyDataBytes() int {
+	return int(s.t.earlyData.Load())
+}
+
+// receivedEarlyData counts the stream data of a 0-RTT packet.
+func (t *tracer) receivedEarlyData(frames []logging.Frame) {
+	for _, f := range frames {
+		if f, ok := f.(*logging.StreamFrame); ok {
+			t.earlyData.Add(int64(f.Length))
+		}
+	}
+}
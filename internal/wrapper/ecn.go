@@ -0,0 +1,45 @@
+package wrapper
+
+import (
+	"net"
+	"syscall"
+)
+
+// noECNConn hides the control message support of a *net.UDPConn from
+// quic-go, which then neither sets nor reads ECN marks. The socket buffer
+// sizes and the DF bit are still set.
+type noECNConn struct {
+	net.PacketConn
+	conn *net.UDPConn
+}
+
+func (c *noECNConn) SetReadBuffer(bytes int) error {
+	return c.conn.SetReadBuffer(bytes)
+}
+
+func (c *noECNConn) SetWriteBuffer(bytes int) error {
+	return c.conn.SetWriteBuffer(bytes)
+}
+
+func (c *noECNConn) SyscallConn() (syscall.RawConn, error) {
+	return c.conn.SyscallConn()
+}
+
+// quicSocket returns the socket to pass to quic-go for pc, applying
+// Config.DisableECN.
+//
+// By default quic-go marks packets with ECT(0) on Linux 5 and later, macOS
+// and FreeBSD, unless QUIC_GO_DISABLE_ECN=true is set; it never uses
+// ECT(1). Sockets other than *net.UDPConn, e.g. those adapted by Client and
+// Server, never carry marks. Disabling ECN also turns off GSO and batch
+// reads for the socket, as quic-go handles all of them through control
+// messages. It has no effect on the shared socket of a Transport or
+// sockets passed to MigrateTo. The marks are reported to a Tracer set
+// through QUICConfigModifier with every packet sent, and can be checked on
+// the wire, e.g. as "tos 0x2" in the output of tcpdump -v.
+func quicSocket(pc net.PacketConn, config *Config) net.PacketConn {
+	if conn, ok := pc.(*net.UDPConn); ok && config.DisableECN {
+		return &noECNConn{PacketConn: conn, conn: conn}
+	}
+	return pc
+}
@@ -0,0 +1,36 @@
+package wrapper
+
+import (
+	"crypto/tls"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// Effective returns the quic.Config the wrapper uses for c: the defaults
+// with QUICConfigModifier applied. The Tracer is the combination with the
+// wrapper's own tracer. A Listener additionally installs a
+// GetConfigForClient callback when AcceptBacklog, HandshakeRateLimit or
+// MaxConcurrentHandshakes is set. It is meant for diagnostics and runs the
+// modifier again; changes to the result have no effect.
+func (c *Config) Effective() *quic.Config {
+	return getQuicConfig(c, newClientTracer(&tracer{}))
+}
+
+// EffectiveTLS returns the tls.Config the wrapper uses for c, after
+// TLSConfigModifier was applied. Secrets are redacted: the private keys of
+// the certificates and the KeyLogWriter are removed. A Listener
+// additionally wraps GetConfigForClient to record the protocols offered by
+// each client, see Effective for the quic.Config callback. It is meant for
+// diagnostics and runs the modifier again; changes to the result have no
+// effect.
+func (c *Config) EffectiveTLS() *tls.Config {
+	tlsConfig := getTLSConfig(c)
+	tlsConfig.KeyLogWriter = nil
+	certificates := make([]tls.Certificate, len(tlsConfig.Certificates))
+	for i, cert := range tlsConfig.Certificates {
+		cert.PrivateKey = nil
+		certificates[i] = cert
+	}
+	tlsConfig.Certificates = certificates
+	return tlsConfig
+}
@@ -0,0 +1,161 @@
+package wrapper
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// EventType is the type of an Event.
+type EventType int
+
+// Events reported by Session.Events.
+const (
+	// EventHandshakeComplete is the first event of every session, unless
+	// it was accepted early, see Config.EarlyAccept.
+	EventHandshakeComplete EventType = iota
+	// EventKeyUpdate is reported when the 1-RTT keys were updated, by
+	// either endpoint.
+	EventKeyUpdate
+	// EventMigration is reported when MigrateTo moved the session to a
+	// new socket.
+	EventMigration
+	// EventStreamOpened is reported for every stream opened locally.
+	EventStreamOpened
+	// EventStreamAccepted is reported for every stream opened by the peer
+	// once it was accepted.
+	EventStreamAccepted
+	// EventClosed is the last event of every session. Err holds the reason.
+	EventClosed
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventHandshakeComplete:
+		return "handshake-complete"
+	case EventKeyUpdate:
+		return "key-update"
+	case EventMigration:
+		return "migration"
+	case EventStreamOpened:
+		return "stream-opened"
+	case EventStreamAccepted:
+		return "stream-accepted"
+	case EventClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a connection-level event of a session.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// StreamID is the stream of EventStreamOpened and EventStreamAccepted.
+	StreamID uint64
+	// Err is the reason of EventClosed.
+	Err error
+}
+
+// eventQueue delivers events to a buffered channel without blocking.
+type eventQueue struct {
+	lock    sync.Mutex
+	ch      chan Event
+	closed  bool
+	dropped atomic.Uint64
+}
+
+func newEventQueue(size int) *eventQueue {
+	return &eventQueue{ch: make(chan Event, size)}
+}
+
+// emit queues e, dropping it if the buffer is full. It is a no-op on a nil
+// queue.
+func (q *eventQueue) emit(e Event) {
+	if q == nil {
+		return
+	}
+	e.Time = time.Now()
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return
+	}
+	select {
+	case q.ch <- e:
+	default:
+		q.dropped.Add(1)
+	}
+}
+
+// close emits the final event and closes the channel.
+func (q *eventQueue) close(e Event) {
+	q.emit(e)
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	close(q.ch)
+}
+
+// startEvents enables the session's events.
+func (s *Session) startEvents(size int) {
+	s.events = newEventQueue(size)
+	s.t.lock.Lock()
+	s.t.events = s.events
+	s.t.lock.Unlock()
+
+	select {
+	case <-s.s.HandshakeComplete():
+		s.events.emit(Event{Type: EventHandshakeComplete})
+	default:
+		// accepted early, see Config.EarlyAccept
+		go func() {
+			select {
+			case <-s.s.HandshakeComplete():
+				s.events.emit(Event{Type: EventHandshakeComplete})
+			case <-s.s.Context().Done():
+			}
+		}()
+	}
+	go func() {
+		<-s.s.Context().Done()
+		s.events.close(Event{Type: EventClosed, Err: context.Cause(s.s.Context())})
+	}()
+}
+
+// Events returns the channel receiving the session's events, or nil if
+// Config.EventBufferSize is zero. The channel is closed after EventClosed.
+//
+// Events are never blocked on a slow consumer: when the buffer is full,
+// new events are dropped and counted by DroppedEvents. EventClosed can be
+// dropped as well; the channel is closed regardless.
+func (s *Session) Events() <-chan Event {
+	if s.events == nil {
+		return nil
+	}
+	return s.events.ch
+}
+
+// DroppedEvents returns the number of events dropped because the buffer
+// of Events was full.
+func (s *Session) DroppedEvents() uint64 {
+	if s.events == nil {
+		return 0
+	}
+	return s.events.dropped.Load()
+}
+
+// streamEvent reports a new stream of the session.
+func (s *Session) streamEvent(id quic.StreamID) {
+	typ := EventStreamAccepted
+	if isLocalStream(id, s.client) {
+		typ = EventStreamOpened
+	}
+	s.events.emit(Event{Type: typ, StreamID: uint64(id)})
+}
@@ -6,18 +6,20 @@ import (
 )
 
 type fakePacketConn struct {
-	c net.Conn
+	c     net.Conn
+	raddr net.Addr // reported as the source of all packets
 }
 
-func newFakePacketConn(conn net.Conn) *fakePacketConn {
+func newFakePacketConn(conn net.Conn, raddr net.Addr) *fakePacketConn {
 	return &fakePacketConn{
-		c: conn,
+		c:     conn,
+		raddr: raddr,
 	}
 }
 
 func (c *fakePacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
 	n, err := c.c.Read(p)
-	return n, c.c.RemoteAddr(), err
+	return n, c.raddr, err
 }
 
 func (c *fakePacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
@@ -0,0 +1,96 @@
+package wrapper
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// FallbackDelay is how long DialWithFallback gives the QUIC handshake a head
+// start before the fallback dial is raced against it.
+const FallbackDelay = 300 * time.Millisecond
+
+type dialResult struct {
+	s        *Session
+	c        net.Conn
+	err      error
+	fallback bool
+}
+
+// DialWithFallback dials addr over quic and races it against fallback, in the
+// spirit of happy eyeballs (RFC 8305). The QUIC dial starts immediately; the
+// fallback is started once FallbackDelay passes without a QUIC session, or as
+// soon as the QUIC dial fails. The first successful attempt wins and the other
+// one is aborted or closed.
+//
+// Exactly one of the returned Session and net.Conn is non-nil on success,
+// telling the caller which transport won. If both attempts fail, the error
+// of the QUIC dial is returned.
+func DialWithFallback(ctx context.Context, addr string, config *Config, fallback func() (net.Conn, error)) (*Session, net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, 2)
+	go func() {
+		s, err := DialContext(ctx, addr, config)
+		results <- dialResult{s: s, err: err}
+	}()
+
+	pending := 1
+	fallbackStarted := false
+	startFallback := func() {
+		fallbackStarted = true
+		pending++
+		go func() {
+			c, err := fallback()
+			results <- dialResult{c: c, err: err, fallback: true}
+		}()
+	}
+
+	timer := time.NewTimer(FallbackDelay)
+	defer timer.Stop()
+
+	var quicErr, fallbackErr error
+	for {
+		select {
+		case <-timer.C:
+			if !fallbackStarted {
+				startFallback()
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				go closeDialResults(results, pending)
+				return r.s, r.c, nil
+			}
+			if r.fallback {
+				fallbackErr = r.err
+			} else {
+				quicErr = r.err
+			}
+			if !fallbackStarted && ctx.Err() == nil {
+				startFallback()
+				continue
+			}
+			if pending == 0 {
+				if quicErr != nil {
+					return nil, nil, quicErr
+				}
+				return nil, nil, fallbackErr
+			}
+		}
+	}
+}
+
+// closeDialResults closes the losing attempts of DialWithFallback.
+func closeDialResults(results <-chan dialResult, pending int) {
+	for ; pending > 0; pending-- {
+		r := <-results
+		switch {
+		case r.s != nil:
+			_ = r.s.Close()
+		case r.c != nil:
+			_ = r.c.Close()
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package wrapper
+
+// Features summarizes the QUIC features in use on a session.
+type Features struct {
+	// Datagrams is true if both endpoints advertised support for
+	// QUIC datagrams (RFC 9221).
+	Datagrams bool
+	// Used0RTT is true if the session was resumed using 0-RTT.
+	Used0RTT bool
+	// KeyUpdate is true if the session supports key updates. Key updates
+	// are mandatory in QUIC, so this only reports false before the
+	// handshake has completed.
+	KeyUpdate bool
+	// Migration is true if the peer did not disable active connection
+	// migration. Only clients can initiate a migration.
+	Migration bool
+}
+
+// Features returns a summary of the features negotiated on the session.
+func (s *Session) Features() Features {
+	state := s.s.ConnectionState()
+
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+
+	f := Features{
+		Used0RTT:  state.Used0RTT,
+		KeyUpdate: state.TLS.HandshakeComplete,
+	}
+	if s.t.localParams != nil {
+		f.Datagrams = state.SupportsDatagrams && s.t.localParams.MaxDatagramFrameSize > 0
+	}
+	if s.t.peerParams != nil {
+		f.Migration = !s.t.peerParams.DisableActiveMigration
+	}
+	return f
+}
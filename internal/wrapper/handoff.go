@@ -0,0 +1,66 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+var errNotUDPConn = errors.New("quic: listener is not backed by a UDP socket")
+
+// UDPConn returns the UDP socket the listener reads from, or nil if it
+// was created over a conn of another type, e.g. by Server.
+func (l *Listener) UDPConn() *net.UDPConn {
+	return udpConn(l.pc)
+}
+
+// File returns a duplicate of the listener's socket file descriptor for
+// handing the listener over to another process, e.g. through
+// exec.Cmd.ExtraFiles. It is not supported on Windows.
+//
+// A graceful upgrade works as follows:
+//  1. The parent passes the socket returned by File to the child.
+//  2. The child calls ListenFile and starts accepting.
+//  3. The parent calls Shutdown, so it accepts no more sessions and waits
+//     for its active ones to end.
+//
+// While both processes hold the socket the kernel hands each packet to
+// either of them. Packets of the parent's sessions that reach the child
+// are dropped, which the sessions handle as loss, so active sessions see a
+// degraded path until the parent exits. Keep the period short, or use
+// SO_REUSEPORT with connection ID aware routing where that matters.
+func (l *Listener) File() (*os.File, error) {
+	conn := l.UDPConn()
+	if conn == nil {
+		return nil, errNotUDPConn
+	}
+	return conn.File()
+}
+
+// ListenFile listens for QUIC sessions on the UDP socket f, typically
+// inherited from another process, see Listener.File. f is duplicated, so
+// the caller can close it.
+func ListenFile(f *os.File, config *Config) (*Listener, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	tracers := newTracerRegistry(config)
+	quicConfig := getQuicConfig(config, tracers.newTracer)
+	tracers.limitIncoming(quicConfig, config)
+
+	pc, err := net.FilePacketConn(f)
+	if err != nil {
+		return nil, err
+	}
+	checkReceiveBuffer(pc, config)
+
+	l, err := listen(quicSocket(pc, config), getTLSConfig(config), quicConfig, config, tracers)
+	if err != nil {
+		if cerr := pc.Close(); cerr != nil {
+			err = fmt.Errorf("failed to close socket (%s) after listen failed: %w", cerr, err)
+		}
+		return nil, err
+	}
+	return newListener(l, tracers, pc, true, config), nil
+}
@@ -0,0 +1,116 @@
+package wrapper
+
+import (
+	"crypto/tls"
+	"slices"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// HandshakeDetails describes the outcome of a session's TLS handshake.
+type HandshakeDetails struct {
+	// NegotiatedProtocol is the application protocol selected via ALPN.
+	NegotiatedProtocol string
+	// TLSVersion is the TLS version used, see tls.VersionName.
+	TLSVersion uint16
+	// CipherSuite is the cipher suite used, see tls.CipherSuiteName.
+	CipherSuite uint16
+	// DidResume is true if the session resumed a previous TLS session.
+	DidResume bool
+	// Offered0RTT is true if the client attempted 0-RTT. It is only
+	// known on the client side.
+	Offered0RTT bool
+	// Used0RTT is true if 0-RTT data was accepted.
+	Used0RTT bool
+	// ClientAuth is true if the client authenticated with a certificate.
+	// On the client this reports whether the server requested one.
+	ClientAuth bool
+}
+
+// HandshakeDetails returns diagnostic details about the TLS handshake.
+// They are only complete once the handshake finished.
+func (s *Session) HandshakeDetails() HandshakeDetails {
+	state := s.s.ConnectionState()
+
+	d := HandshakeDetails{
+		NegotiatedProtocol: state.TLS.NegotiatedProtocol,
+		TLSVersion:         state.TLS.Version,
+		CipherSuite:        state.TLS.CipherSuite,
+		DidResume:          state.TLS.DidResume,
+		Used0RTT:           state.Used0RTT,
+	}
+
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+	d.Offered0RTT = s.t.offered0RTT
+	if s.client {
+		d.ClientAuth = s.t.clientAuth
+	} else {
+		d.ClientAuth = len(state.TLS.PeerCertificates) > 0
+	}
+	return d
+}
+
+// ConnectedAt returns the time the session's connection was started: on
+// the client when it sent its first packet, on the server when it
+// received the client's first packet that it did not answer with a Retry.
+func (s *Session) ConnectedAt() time.Time {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+	return s.t.connectedAt
+}
+
+// HandshakeCompletedAt returns the time the handshake completed, i.e. when
+// the channel returned by HandshakeComplete was closed. It is the zero
+// time before that. The client completes the handshake when it received
+// the server's Finished message, the server one round trip later with the
+// client's.
+func (s *Session) HandshakeCompletedAt() time.Time {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+	return s.t.completedAt
+}
+
+// HandshakeDuration returns the time from ConnectedAt to
+// HandshakeCompletedAt, or zero if the handshake did not complete yet.
+func (s *Session) HandshakeDuration() time.Duration {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+	if s.t.completedAt.IsZero() {
+		return 0
+	}
+	return s.t.completedAt.Sub(s.t.connectedAt)
+}
+
+// OfferedProtocols returns the application protocols the client offered
+// through ALPN, in its order of preference. On the server, they are the
+// ones received in the client's ClientHello. Together with the
+// NegotiatedProtocol of HandshakeDetails, the protocol the server
+// selected, it helps to debug ALPN mismatches.
+func (s *Session) OfferedProtocols() []string {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+	return slices.Clone(s.t.offeredProtocols)
+}
+
+// recordOfferedProtocols makes the server's tlsConfig record the
+// protocols offered by each client in the tracer of its connection.
+func (r *tracerRegistry) recordOfferedProtocols(tlsConfig *tls.Config) {
+	getConfigForClient := tlsConfig.GetConfigForClient
+	tlsConfig.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		id, _ := hello.Context().Value(quic.ConnectionTracingKey).(quic.ConnectionTracingID)
+		r.lock.Lock()
+		t := r.tracers[id]
+		r.lock.Unlock()
+		if t != nil {
+			t.lock.Lock()
+			t.offeredProtocols = slices.Clone(hello.SupportedProtos)
+			t.lock.Unlock()
+		}
+		if getConfigForClient != nil {
+			return getConfigForClient(hello)
+		}
+		return nil, nil
+	}
+}
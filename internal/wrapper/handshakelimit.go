@@ -0,0 +1,52 @@
+package wrapper
+
+import (
+	"crypto/tls"
+	"net"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// transportListener is a listener on a quic.Transport of its own, which
+// is closed by the Listener once no session needs it anymore.
+type transportListener struct {
+	quicListener
+	tr *quic.Transport
+}
+
+// listenTransport listens like listen on a quic.Transport that sends a
+// Retry to new clients while the handshake limit is reached. A Retry costs
+// the server no state; clients still finding the limit reached once they
+// proved their address are refused by limitIncoming.
+func listenTransport(pc net.PacketConn, tlsConfig *tls.Config, quicConfig *quic.Config, config *Config, tracers *tracerRegistry) (quicListener, error) {
+	tr := &quic.Transport{
+		Conn: pc,
+		VerifySourceAddress: func(net.Addr) bool {
+			return tracers.saturated(config.MaxConcurrentHandshakes)
+		},
+	}
+	var l quicListener
+	var err error
+	if config.EarlyAccept {
+		l, err = tr.ListenEarly(tlsConfig, quicConfig)
+	} else {
+		l, err = tr.Listen(tlsConfig, quicConfig)
+	}
+	if err != nil {
+		_ = tr.Close()
+		return nil, err
+	}
+	return &transportListener{quicListener: l, tr: tr}, nil
+}
+
+// saturated reports whether limit handshakes are in progress. A limit of
+// zero means no limit.
+func (r *tracerRegistry) saturated(limit int) bool {
+	return limit > 0 && r.handshakes.Load() >= int64(limit)
+}
+
+// HandshakesInFlight returns the number of incoming connections whose
+// handshake is in progress.
+func (l *Listener) HandshakesInFlight() int {
+	return int(l.tracers.handshakes.Load())
+}
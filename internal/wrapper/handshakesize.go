@@ -0,0 +1,33 @@
+package wrapper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+var errHandshakeMessageTooLarge = errors.New("quic: handshake message exceeds maximum size")
+
+// limitHandshakeMessages makes tlsConfig reject peer certificate chains
+// larger than limit bytes, after any verification set by the modifier.
+// crypto/tls does not expose the size of other handshake messages, so the
+// ClientHello is not covered. Regardless of the limit, quic-go accepts at
+// most 16 kB of handshake data per encryption level and closes connections
+// exceeding it with CRYPTO_BUFFER_EXCEEDED, and crypto/tls refuses
+// messages larger than 64 kB.
+func limitHandshakeMessages(tlsConfig *tls.Config, limit int) {
+	verify := tlsConfig.VerifyPeerCertificate
+	tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, chains [][]*x509.Certificate) error {
+		size := 0
+		for _, raw := range rawCerts {
+			size += len(raw)
+		}
+		if size > limit {
+			return errHandshakeMessageTooLarge
+		}
+		if verify != nil {
+			return verify(rawCerts, chains)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,90 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newOversizedChain returns a certificate whose chain carries padding
+// certificates of about size bytes in total.
+func newOversizedChain(t *testing.T, size int) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	cert := newTestCertificate(t, key)
+	for size > 0 {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "padding"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtraExtensions: []pkix.Extension{
+				{Id: []int{1, 3, 6, 1, 4, 1, 99999, 1}, Value: make([]byte, 2000)},
+			},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		assert.NoError(t, err)
+		cert.Certificate = append(cert.Certificate, der)
+		size -= len(der)
+	}
+	return cert
+}
+
+func TestConfig_MaxHandshakeMessageSize(t *testing.T) {
+	testCases := []struct {
+		name      string
+		chainSize int
+		limit     int
+		expectErr string
+	}{
+		{"Unlimited", 8000, 0, ""},
+		{"WithinLimit", 4000, 8000, ""},
+		{"ExceedsLimit", 8000, 4000, errHandshakeMessageTooLarge.Error()},
+		{"ExceedsQuicGo", 20000, 0, "CRYPTO_BUFFER_EXCEEDED"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			serverConfig := &Config{
+				Certificates: []tls.Certificate{newOversizedChain(t, tc.chainSize)},
+				SkipVerify:   true,
+			}
+			clientConfig := newTestConfig(t)
+			clientConfig.MaxHandshakeMessageSize = tc.limit
+
+			l, err := Listen("127.0.0.1:0", serverConfig)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer func() { _ = l.Close() }()
+			go func() {
+				if s, err := l.Accept(); err == nil {
+					_ = s.Close()
+				}
+			}()
+
+			s, err := Dial(l.l.Addr().String(), clientConfig)
+			if tc.expectErr != "" {
+				assert.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				_ = s.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package wrapper
+
+import (
+	"context"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// errorCodeIdle is the stream error code used when a stream is reset by
+// the idle stream sweeper, see Config.StreamIdleTimeout.
+const errorCodeIdle quic.StreamErrorCode = 2
+
+// minIdleSweepInterval bounds how often idle streams are looked for.
+const minIdleSweepInterval = 10 * time.Millisecond
+
+// touch records activity on the stream.
+func (s *Stream) touch() {
+	s.lastActive.Store(time.Now().UnixNano())
+}
+
+// idleSince returns the time of the last activity on the stream.
+func (s *Stream) idleSince() time.Time {
+	return time.Unix(0, s.lastActive.Load())
+}
+
+// finished reports whether both sides of the stream ended.
+func (s *Stream) finished() bool {
+	return s.ended.Load() && s.s.Context().Err() != nil
+}
+
+// OpenStreamWithIdleTimeout opens a new stream like OpenStreamOpts that is
+// reset in both directions if no bytes are read or written for d, guarding
+// against streams that are opened and then forgotten.
+//
+// The timer starts when the stream is opened and restarts with every read or
+// write that transfers data; calls blocked waiting for data do not count as
+// activity. Deadlines set on the stream are independent: a passed deadline
+// makes pending calls fail without resetting the stream, while the idle
+// timer resets it even if a call is blocked without a deadline.
+func (s *Session) OpenStreamWithIdleTimeout(ctx context.Context, d time.Duration) (*Stream, error) {
+	return s.OpenStreamOpts(ctx, StreamOptions{IdleTimeout: d})
+}
+
+// startIdleTimer resets the stream with code once it was idle for d.
+func (s *Stream) startIdleTimer(d time.Duration, code quic.StreamErrorCode) {
+	var check func()
+	check = func() {
+		if s.finished() {
+			return
+		}
+		idle := time.Since(s.idleSince())
+		if idle >= d {
+			s.cancel(code)
+			return
+		}
+		time.AfterFunc(d-idle, check)
+	}
+	time.AfterFunc(d, check)
+}
+
+// trackStream registers str with the idle stream sweeper, if enabled.
+func (s *Session) trackStream(str *Stream) *Stream {
+	if s.idleTimeout <= 0 {
+		return str
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.streams == nil {
+		s.streams = make(map[*Stream]struct{})
+	}
+	s.streams[str] = struct{}{}
+	return str
+}
+
+// sweepIdleStreams resets the streams without activity for longer than
+// the session's idle timeout until the session is closed. Streams are
+// checked every half of the timeout, so a stream may stay idle for up to
+// 1.5 times the timeout.
+func (s *Session) sweepIdleStreams() {
+	interval := s.idleTimeout / 2
+	if interval < minIdleSweepInterval {
+		interval = minIdleSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.s.Context().Done():
+			return
+		case now := <-ticker.C:
+			for _, str := range s.takeIdleStreams(now) {
+				str.cancel(errorCodeIdle)
+			}
+		}
+	}
+}
+
+// takeIdleStreams stops tracking finished streams and returns the ones
+// idle at now.
+func (s *Session) takeIdleStreams(now time.Time) []*Stream {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var idle []*Stream
+	for str := range s.streams {
+		switch {
+		case str.finished():
+			delete(s.streams, str)
+		case now.Sub(str.idleSince()) > s.idleTimeout:
+			delete(s.streams, str)
+			idle = append(idle, str)
+		}
+	}
+	return idle
+}
@@ -0,0 +1,80 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_StreamIdleTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	serverConfig := newTestConfig(t)
+	serverConfig.StreamIdleTimeout = timeout
+	server, client := newTestSessions(t, serverConfig, newTestConfig(t))
+	go func() {
+		for {
+			str, err := server.AcceptStream()
+			if err != nil || str == nil {
+				return
+			}
+			go func() { _, _ = io.Copy(io.Discard, str) }()
+		}
+	}()
+
+	idle, err := client.OpenStream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = idle.Write([]byte("idle"), false)
+	assert.NoError(t, err)
+	active, err := client.OpenStream()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The server resets both directions of the idle stream with code 2,
+	// while the active one keeps going.
+	start := time.Now()
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := idle.Read(make([]byte, 1))
+		readErr <- err
+	}()
+	var streamErr *quic.StreamError
+	for time.Since(start) < 3*timeout {
+		_, err := active.Write([]byte("active"), false)
+		if !assert.NoError(t, err) {
+			return
+		}
+		time.Sleep(timeout / 10)
+	}
+	select {
+	case err := <-readErr:
+		if assert.True(t, errors.As(err, &streamErr)) {
+			assert.Equal(t, errorCodeIdle, streamErr.ErrorCode)
+		}
+	default:
+		t.Fatal("idle stream not reset")
+	}
+	assert.Eventually(t, func() bool {
+		_, err := idle.Write([]byte("late"), false)
+		return errors.As(err, &streamErr) && streamErr.ErrorCode == errorCodeIdle
+	}, time.Second, 10*time.Millisecond, "the write side is reset too")
+
+	// The session is not affected.
+	assert.False(t, client.IsClosed())
+	str, err := client.OpenStream()
+	if assert.NoError(t, err) {
+		_, err = str.WriteQuic([]byte("after"), true)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, active.Close())
+}
@@ -0,0 +1,123 @@
+package wrapper
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+var (
+	errBacklogFull        = errors.New("quic: accept backlog full")
+	errHandshakeRateLimit = errors.New("quic: handshake rate limit exceeded")
+	errTooManyHandshakes  = errors.New("quic: too many concurrent handshakes")
+)
+
+// limitIncoming makes quicConfig refuse new connections while the accept
+// backlog is full, too many handshakes are in progress or the handshake
+// rate is exceeded. Connections are refused before the TLS handshake
+// starts.
+//
+// quic-go queues at most 32 established connections itself, and refuses
+// further ones regardless of the backlog. Clients are checked after address
+// validation, so a server sending Retry packets only counts clients that
+// proved their address.
+func (r *tracerRegistry) limitIncoming(quicConfig *quic.Config, config *Config) {
+	backlog := config.AcceptBacklog
+	var bucket *tokenBucket
+	if config.HandshakeRateLimit > 0 {
+		bucket = newTokenBucket(config.HandshakeRateLimit)
+	}
+	if backlog <= 0 && config.MaxConcurrentHandshakes <= 0 && bucket == nil {
+		return
+	}
+
+	getConfigForClient := quicConfig.GetConfigForClient
+	quicConfig.GetConfigForClient = func(info *quic.ClientInfo) (*quic.Config, error) {
+		if backlog > 0 && r.len() >= backlog {
+			return nil, errBacklogFull
+		}
+		if r.saturated(config.MaxConcurrentHandshakes) {
+			return nil, errTooManyHandshakes
+		}
+		if bucket != nil && !bucket.take(time.Now()) {
+			r.rateLimited.Add(1)
+			return nil, errHandshakeRateLimit
+		}
+		if getConfigForClient != nil {
+			return getConfigForClient(info)
+		}
+		return quicConfig, nil
+	}
+}
+
+// len returns the number of connections not accepted yet.
+func (r *tracerRegistry) len() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.tracers)
+}
+
+// Backlog returns the number of incoming connections that are handshaking
+// or waiting for Accept.
+func (l *Listener) Backlog() int {
+	return l.tracers.len()
+}
+
+// RateLimitedHandshakes returns the number of connection attempts refused
+// because of Config.HandshakeRateLimit.
+func (l *Listener) RateLimitedHandshakes() uint64 {
+	return l.tracers.rateLimited.Load()
+}
+
+// tokenBucket allows rate events per second with bursts of up to one
+// second worth of events.
+type tokenBucket struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// take removes a token, reporting false if none is left.
+func (b *tokenBucket) take(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// refill adds the tokens accumulated since the last refill. The lock must
+// be held.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// delay returns how long it takes until n tokens are available, or as
+// many as the burst if n exceeds it, so that large costs are not refused
+// forever. The lock must be held.
+func (b *tokenBucket) delay(n float64) time.Duration {
+	missing := min(n, b.burst) - b.tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.rate * float64(time.Second))
+}
@@ -2,13 +2,44 @@ package wrapper
 
 import (
 	"context"
+	"net"
+	"sync"
+	"time"
 
 	quic "github.com/quic-go/quic-go"
 )
 
+// DrainTimeout is how long DrainOn waits for active sessions to end
+// before closing them.
+const DrainTimeout = 10 * time.Second
+
 // A Listener for incoming QUIC connections
 type Listener struct {
-	l *quic.Listener
+	l       quicListener
+	tracers *tracerRegistry
+	pc      net.PacketConn
+	config  *Config
+
+	// ownsConn is set if the socket was created by the wrapper. It is
+	// closed once the listener and all accepted sessions are closed.
+	ownsConn bool
+
+	lock     sync.Mutex
+	sessions map[*Session]struct{}
+	closed   bool
+	idle     chan struct{} // closed once closed and all sessions ended
+}
+
+func newListener(l quicListener, tracers *tracerRegistry, pc net.PacketConn, ownsConn bool, config *Config) *Listener {
+	return &Listener{
+		l:        l,
+		tracers:  tracers,
+		pc:       pc,
+		config:   config,
+		ownsConn: ownsConn,
+		sessions: make(map[*Session]struct{}),
+		idle:     make(chan struct{}),
+	}
 }
 
 // Accept accepts incoming streams
@@ -17,10 +48,111 @@ func (l *Listener) Accept() (*Session, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Session{s: s}, nil
+
+	session := newSession(s, l.tracers.take(s), false, l.config)
+	session.pc = l.pc
+
+	l.lock.Lock()
+	if l.closed {
+		// Close released the socket while the session was being set up.
+		l.lock.Unlock()
+		_ = session.Close()
+		return nil, quic.ErrServerClosed
+	}
+	l.sessions[session] = struct{}{}
+	l.lock.Unlock()
+	go func() {
+		<-s.Context().Done()
+
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		delete(l.sessions, session)
+		if l.closed && len(l.sessions) == 0 {
+			_ = l.finish()
+		}
+	}()
+
+	return session, nil
 }
 
-// Close closes the listener
+// Close closes the listener. Sessions that were already accepted are
+// not affected, those still waiting for Accept are closed.
 func (l *Listener) Close() error {
-	return l.l.Close()
-}
\ No newline at end of file
+	err := l.l.Close()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return err
+	}
+	l.closed = true
+	if len(l.sessions) == 0 {
+		if cerr := l.finish(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Shutdown closes the listener and waits for all accepted sessions to end.
+// If ctx is done first, the remaining sessions are closed and the
+// context's error is returned.
+func (l *Listener) Shutdown(ctx context.Context) error {
+	err := l.Close()
+
+	select {
+	case <-l.idle:
+		return err
+	case <-ctx.Done():
+	}
+
+	l.lock.Lock()
+	sessions := make([]*Session, 0, len(l.sessions))
+	for s := range l.sessions {
+		sessions = append(sessions, s)
+	}
+	l.lock.Unlock()
+
+	for _, s := range sessions {
+		_ = s.Close()
+	}
+	return ctx.Err()
+}
+
+// DrainOn shuts the listener down once ctx is done, giving active sessions
+// DrainTimeout to end before they are closed. The returned channel receives
+// the result of the shutdown. It is meant to be tied to process signals:
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+//	defer stop()
+//	err := <-listener.DrainOn(ctx)
+func (l *Listener) DrainOn(ctx context.Context) <-chan error {
+	errc := make(chan error, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-l.idle:
+			// closed by other means, nothing left to drain
+			errc <- nil
+			return
+		}
+
+		drainCtx, cancel := context.WithTimeout(context.Background(), DrainTimeout)
+		defer cancel()
+		errc <- l.Shutdown(drainCtx)
+	}()
+	return errc
+}
+
+// finish releases the socket once no session needs it anymore.
+// The lock must be held.
+func (l *Listener) finish() error {
+	close(l.idle)
+	if tl, ok := l.l.(*transportListener); ok {
+		_ = tl.tr.Close()
+	}
+	if !l.ownsConn {
+		return nil
+	}
+	return l.pc.Close()
+}
@@ -0,0 +1,146 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListener_AcceptAfterClose(t *testing.T) {
+	l, err := Listen("127.0.0.1:0", newTestConfig(t))
+	if !assert.NoError(t, err) {
+		return
+	}
+	client, err := Dial(l.l.Addr().String(), newTestConfig(t))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer client.Close()
+	// The session waits for Accept.
+	assert.Eventually(t, func() bool {
+		return l.Backlog() == 1 && l.HandshakesInFlight() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		closed <- l.Shutdown(ctx)
+	}()
+	assert.NoError(t, l.Close())
+
+	// quic-go still hands out the queued session, which must not be
+	// registered once the socket was released.
+	s, err := l.Accept()
+	assert.Nil(t, s)
+	assert.ErrorIs(t, err, quic.ErrServerClosed)
+	assert.NoError(t, <-closed)
+}
+
+// acceptAll accepts the sessions of l until it is closed, closing them at
+// the end of the test.
+func acceptAll(t *testing.T, l *Listener) {
+	go func() {
+		for {
+			s, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = s.Close() })
+		}
+	}()
+}
+
+// dialTest dials addr with a fresh client configuration, closing the
+// session at the end of the test.
+func dialTest(t *testing.T, addr string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err := DialContext(ctx, addr, newTestConfig(t))
+	if err == nil {
+		t.Cleanup(func() { _ = s.Close() })
+	}
+	return err
+}
+
+func TestListener_HandshakeRateLimit(t *testing.T) {
+	const rate = 2 // handshakes per second, also the burst
+
+	config := newTestConfig(t)
+	config.HandshakeRateLimit = rate
+	l, err := Listen("127.0.0.1:0", config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	acceptAll(t, l)
+	addr := l.l.Addr().String()
+
+	for i := 0; i < rate; i++ {
+		assert.NoError(t, dialTest(t, addr))
+	}
+	var transportErr *quic.TransportError
+	if assert.ErrorAs(t, dialTest(t, addr), &transportErr) {
+		assert.Equal(t, quic.ConnectionRefused, transportErr.ErrorCode)
+	}
+	// A refused client may count once per Initial packet it sent.
+	assert.NotZero(t, l.RateLimitedHandshakes())
+
+	// The bucket refills.
+	time.Sleep(time.Second / rate)
+	assert.NoError(t, dialTest(t, addr))
+}
+
+func TestListener_MaxConcurrentHandshakes(t *testing.T) {
+	config := newTestConfig(t)
+	config.MaxConcurrentHandshakes = 1
+	config.QUICConfigModifier = func(c *quic.Config) {
+		c.HandshakeIdleTimeout = 500 * time.Millisecond
+	}
+	l, err := Listen("127.0.0.1:0", config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	acceptAll(t, l)
+	addr := l.l.Addr().String()
+
+	// A client that never receives the server's reply stalls its handshake
+	// until the server gives up on it.
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	pc := &blockablePacketConn{PacketConn: udp}
+	pc.blocked.Store(true)
+	tr := NewTransport(pc)
+	t.Cleanup(func() { _ = tr.Close() })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if s, err := tr.Dial(ctx, addr, newTestConfig(t)); err == nil {
+			_ = s.Close()
+		}
+	}()
+	assert.Eventually(t, func() bool {
+		return l.HandshakesInFlight() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	var transportErr *quic.TransportError
+	if assert.ErrorAs(t, dialTest(t, addr), &transportErr) {
+		assert.Equal(t, quic.ConnectionRefused, transportErr.ErrorCode)
+	}
+
+	// The stalled handshake times out, making room for new ones.
+	assert.Eventually(t, func() bool {
+		return l.HandshakesInFlight() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, dialTest(t, addr))
+}
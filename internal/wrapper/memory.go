@@ -0,0 +1,171 @@
+package wrapper
+
+import (
+	"errors"
+	"sync"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/logging"
+)
+
+// errorCodeMemoryLimit is the application error code a session is closed
+// with when it exceeds Config.MaxSessionMemory.
+const errorCodeMemoryLimit = 1
+
+var errMemoryLimit = errors.New("quic: session memory limit exceeded")
+
+// quic-go's initial receive windows used when quic.Config leaves them zero.
+const (
+	defaultInitialStreamReceiveWindow     = 512 << 10 // 512 kB
+	defaultInitialConnectionReceiveWindow = 768 << 10 // 768 kB
+)
+
+// limitReceiveWindows caps the flow control windows of quicConfig to limit
+// bytes, so the peer is held back by flow control rather than buffered
+// without bound.
+func limitReceiveWindows(quicConfig *quic.Config, limit int) {
+	clamp := func(v *uint64, def uint64) {
+		if *v == 0 {
+			*v = def
+		}
+		if *v > uint64(limit) {
+			*v = uint64(limit)
+		}
+	}
+	clamp(&quicConfig.InitialStreamReceiveWindow, defaultInitialStreamReceiveWindow)
+	clamp(&quicConfig.MaxStreamReceiveWindow, quicConfig.InitialStreamReceiveWindow)
+	clamp(&quicConfig.InitialConnectionReceiveWindow, defaultInitialConnectionReceiveWindow)
+	clamp(&quicConfig.MaxConnectionReceiveWindow, quicConfig.InitialConnectionReceiveWindow)
+}
+
+// memoryTracker accounts for the stream data of a session that was
+// received but not read by the application yet. Data kept in the wrapper's
+// read buffers counts towards the limit, although quic-go already released
+// its flow control credit. The limit is checked per received packet.
+// Datagrams are not counted, quic-go queues at most 128 received ones.
+type memoryTracker struct {
+	limit int
+
+	lock     sync.Mutex
+	streams  map[logging.StreamID]*streamMemory
+	usage    int
+	exceeded func() // called once the limit is exceeded
+}
+
+type streamMemory struct {
+	received  logging.ByteCount // highest offset received
+	read      logging.ByteCount
+	final     logging.ByteCount
+	fin       bool
+	discarded bool // reading was canceled, data still arriving is not kept
+}
+
+func newMemoryTracker(limit int) *memoryTracker {
+	return &memoryTracker{limit: limit, streams: make(map[logging.StreamID]*streamMemory)}
+}
+
+// received records a STREAM frame.
+func (m *memoryTracker) received(id logging.StreamID, end logging.ByteCount, fin bool) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	sm, ok := m.streams[id]
+	if !ok {
+		sm = &streamMemory{}
+		m.streams[id] = sm
+	}
+	if end > sm.received {
+		if !sm.discarded {
+			m.usage += int(end - sm.received)
+		}
+		sm.received = end
+	}
+	if sm.discarded {
+		sm.read = sm.received
+	}
+	if fin {
+		sm.final, sm.fin = end, true
+	}
+	m.release(id, sm)
+	exceeded := m.usage > m.limit
+	f := m.exceeded
+	if exceeded {
+		m.exceeded = nil
+	}
+	m.lock.Unlock()
+
+	if exceeded && f != nil {
+		f()
+	}
+}
+
+// read records data read by the application.
+func (m *memoryTracker) read(id quic.StreamID, n int) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	sm, ok := m.streams[id]
+	if !ok {
+		return
+	}
+	read := min(logging.ByteCount(n), sm.received-sm.read)
+	sm.read += read
+	m.usage -= int(read)
+	m.release(id, sm)
+}
+
+// discard drops the data of a stream whose reading was canceled locally.
+// The stream is kept until the peer finished or reset it, which it does
+// in response, so that data still arriving is not counted.
+func (m *memoryTracker) discard(id quic.StreamID) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	sm, ok := m.streams[id]
+	if !ok {
+		return
+	}
+	m.usage -= int(sm.received - sm.read)
+	sm.read = sm.received
+	sm.discarded = true
+	m.release(id, sm)
+}
+
+// reset forgets a stream whose data was dropped by the peer resetting it.
+func (m *memoryTracker) reset(id quic.StreamID) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if sm, ok := m.streams[id]; ok {
+		m.usage -= int(sm.received - sm.read)
+		delete(m.streams, id)
+	}
+}
+
+// release forgets a stream that was read completely. The lock must be held.
+func (m *memoryTracker) release(id logging.StreamID, sm *streamMemory) {
+	if sm.fin && sm.read >= sm.final {
+		delete(m.streams, id)
+	}
+}
+
+// MemoryUsage returns the number of stream bytes the session received that
+// the application did not read yet, including data held in the wrapper's
+// read buffers. It is zero unless Config.MaxSessionMemory is set. Data read
+// from a detached stream is not accounted for.
+func (s *Session) MemoryUsage() int {
+	m := s.t.mem
+	if m == nil {
+		return 0
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.usage
+}
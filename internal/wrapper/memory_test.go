@@ -0,0 +1,80 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_MaxSessionMemory(t *testing.T) {
+	const limit, size = 64 << 10, 1 << 20
+
+	testCases := []struct {
+		name string
+		// readBufferSize is the client's ReceiveBufferSize; data it holds
+		// counts towards the limit without holding back the server.
+		readBufferSize int
+		exceeded       bool
+	}{
+		{"Unbuffered", 0, false},
+		{"Buffered", maxStreamBufferSize, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig := newTestConfig(t)
+			clientConfig.MaxSessionMemory = limit
+			server, client := newTestSessions(t, newTestConfig(t), clientConfig)
+			go func() {
+				str, err := server.AcceptStream()
+				if err != nil || str == nil {
+					return
+				}
+				_, _ = str.WriteQuic(make([]byte, size), true)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			str, err := client.OpenStreamOpts(ctx, StreamOptions{ReceiveBufferSize: tc.readBufferSize})
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = str.Write([]byte("request"), false)
+			assert.NoError(t, err)
+
+			if !tc.exceeded {
+				// Flow control holds the server back while the body is read.
+				n, err := io.Copy(io.Discard, str)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(size), n)
+				assert.False(t, client.IsClosed())
+				assert.Eventually(t, func() bool {
+					return client.MemoryUsage() == 0
+				}, 5*time.Second, 10*time.Millisecond)
+				return
+			}
+
+			// Reading a byte fills the read buffer from quic-go, releasing
+			// flow control credit the server uses to exceed the limit.
+			_, err = str.Read(make([]byte, 1))
+			assert.NoError(t, err)
+			select {
+			case <-client.Context().Done():
+			case <-ctx.Done():
+				t.Fatal("session not closed")
+			}
+			var appErr *quic.ApplicationError
+			if assert.ErrorAs(t, context.Cause(client.Context()), &appErr) {
+				assert.Equal(t, quic.ApplicationErrorCode(errorCodeMemoryLimit), appErr.ErrorCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package wrapper
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+)
+
+// DefaultMaxMessageSize is the message size limit used by NewMessageStream
+// if none is given.
+const DefaultMaxMessageSize = 4 << 20 // 4 MB
+
+// messageHeaderSize is the size of the length preceding each message.
+const messageHeaderSize = 4
+
+// ErrMessageTooLarge is returned for a message exceeding the size limit of
+// a length-prefixed reader or writer.
+var ErrMessageTooLarge = errors.New("quic: message exceeds maximum size")
+
+// Codec marshals the values sent over a MessageStream.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec is a Codec using encoding/json. It is the default codec of a
+// MessageStream.
+type JSONCodec struct{}
+
+// Marshal implements Codec.
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements Codec.
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// MessageStream sends and receives values over a Stream, each encoded by
+// a Codec and prefixed with its length as a 32 bit integer. Encode and
+// Decode may be called concurrently with each other, but each must not be
+// called concurrently with itself.
+type MessageStream struct {
+	s       *Stream
+	codec   Codec
+	maxSize int
+}
+
+// NewMessageStream creates a MessageStream over s using JSONCodec. maxSize
+// limits the size of encoded messages in both directions; zero or less
+// selects DefaultMaxMessageSize. The limit protects the reader from
+// allocating memory for sizes announced by the peer: a larger message is
+// rejected with ErrMessageTooLarge before its body is read, and the stream
+// is reset. The limit cannot exceed the 32 bit length.
+func NewMessageStream(s *Stream, maxSize int) *MessageStream {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+	maxSize = int(min(uint64(maxSize), math.MaxUint32))
+	return &MessageStream{s: s, codec: JSONCodec{}, maxSize: maxSize}
+}
+
+// SetCodec replaces the codec, e.g. by one for protobuf.
+func (m *MessageStream) SetCodec(c Codec) {
+	m.codec = c
+}
+
+// Stream returns the underlying stream.
+func (m *MessageStream) Stream() *Stream {
+	return m.s
+}
+
+// Encode marshals v and writes it as one message.
+func (m *MessageStream) Encode(v any) error {
+	data, err := m.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(data) > m.maxSize {
+		return ErrMessageTooLarge
+	}
+
+	frame := make([]byte, messageHeaderSize+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[messageHeaderSize:], data)
+	if _, err := m.s.write(frame); err != nil {
+		return err
+	}
+	return m.s.Flush()
+}
+
+// Decode reads the next message and unmarshals it into v. It returns
+// io.EOF once the peer finished the stream between two messages.
+func (m *MessageStream) Decode(v any) error {
+	var header [messageHeaderSize]byte
+	if _, err := io.ReadFull(m.s, header[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if uint64(size) > uint64(m.maxSize) {
+		m.s.cancel(errorCodeCanceled)
+		return ErrMessageTooLarge
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(m.s, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return m.codec.Unmarshal(data, v)
+}
@@ -0,0 +1,50 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"errors"
+	"testing"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageStream_RoundTrip(t *testing.T) {
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+	str, err := client.OpenStream()
+	assert.NoError(t, err)
+	type message struct{ Text string }
+	assert.NoError(t, NewMessageStream(str, 0).Encode(message{Text: "hello"}))
+
+	accepted, err := server.AcceptStream()
+	assert.NoError(t, err)
+	var got message
+	assert.NoError(t, NewMessageStream(accepted, 0).Decode(&got))
+	assert.Equal(t, "hello", got.Text)
+}
+
+func TestMessageStream_TooLarge(t *testing.T) {
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+	str, err := client.OpenStream()
+	assert.NoError(t, err)
+	// announce a 4 GB message without sending it
+	_, err = str.Write([]byte{0xff, 0xff, 0xff, 0xff}, false)
+	assert.NoError(t, err)
+
+	accepted, err := server.AcceptStream()
+	assert.NoError(t, err)
+	var v any
+	err = NewMessageStream(accepted, 1024).Decode(&v)
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+
+	// the stream was reset
+	_, err = str.Read(make([]byte, 1))
+	var streamErr *quic.StreamError
+	if assert.True(t, errors.As(err, &streamErr)) {
+		assert.Equal(t, errorCodeCanceled, streamErr.ErrorCode)
+	}
+}
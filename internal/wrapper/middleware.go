@@ -0,0 +1,79 @@
+package wrapper
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"slices"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// StreamHandler handles an incoming stream. ctx carries the session the
+// stream belongs to, see SessionFromContext, and is done once serving
+// stops.
+type StreamHandler func(ctx context.Context, str *Stream)
+
+// Middleware wraps a StreamHandler, e.g. to run code before and after it
+// or to handle the stream itself without calling it.
+type Middleware func(StreamHandler) StreamHandler
+
+// Chain combines middleware into one, the first being the outermost: the
+// stream passes through them in the order given before reaching the
+// handler.
+func Chain(middleware ...Middleware) Middleware {
+	return func(h StreamHandler) StreamHandler {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			h = middleware[i](h)
+		}
+		return h
+	}
+}
+
+type sessionContextKey struct{}
+
+// SessionFromContext returns the session of the context passed to a
+// StreamHandler, or nil if there is none.
+func SessionFromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionContextKey{}).(*Session)
+	return s
+}
+
+// ServeHandler serves incoming streams with h like ServeStreams, passing
+// it a context derived from ctx that carries the session.
+func (s *Session) ServeHandler(ctx context.Context, h StreamHandler, workers int) error {
+	hctx := context.WithValue(ctx, sessionContextKey{}, s)
+	return s.ServeStreams(ctx, func(str *Stream) {
+		h(hctx, str)
+	}, workers)
+}
+
+// CertificateFingerprint returns the SHA-256 hash of the DER encoding of
+// cert, as checked by RequireCertificateFingerprint.
+func CertificateFingerprint(cert *x509.Certificate) [sha256.Size]byte {
+	return sha256.Sum256(cert.Raw)
+}
+
+// RequireCertificateFingerprint is a middleware passing only the streams of
+// peers whose leaf certificate has one of the allowed fingerprints, see
+// CertificateFingerprint. Other streams are reset in both directions with
+// code. Servers request a certificate from every client without verifying
+// it, so pinning its fingerprint is what authenticates the client; the
+// handshake proved that the client holds the certificate's key.
+func RequireCertificateFingerprint(code uint16, allowed ...[sha256.Size]byte) Middleware {
+	return func(next StreamHandler) StreamHandler {
+		return func(ctx context.Context, str *Stream) {
+			s := SessionFromContext(ctx)
+			if s == nil {
+				str.cancel(quic.StreamErrorCode(code))
+				return
+			}
+			certs := s.GetRemoteCertificates()
+			if len(certs) == 0 || !slices.Contains(allowed, CertificateFingerprint(certs[0])) {
+				str.cancel(quic.StreamErrorCode(code))
+				return
+			}
+			next(ctx, str)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// MigrationProbeTimeout is how long MigrateTo waits for the new path to
+// be validated.
+const MigrationProbeTimeout = 5 * time.Second
+
+var (
+	errMigrationServer   = errors.New("quic: only the client can migrate a session")
+	errMigrationDisabled = errors.New("quic: peer disabled active migration")
+)
+
+// MigrateTo moves the session to the local socket pc, e.g. after the
+// client switched networks. The new path is probed first and the session
+// is only switched over once the peer answered; on failure the session
+// keeps using its current path.
+//
+// This is active migration: the client decides to move. Passive migration,
+// where the client's address changes underneath it, e.g. by a NAT rebinding,
+// is handled by quic-go without any call. Only clients can migrate, and
+// only if the server did not set disable_active_migration in its
+// transport parameters.
+//
+// pc is not closed by the session and must stay open until the session
+// ends, even after a later migration or a failed one. The previous socket
+// is not closed either.
+func (s *Session) MigrateTo(pc net.PacketConn) error {
+	if !s.client {
+		return errMigrationServer
+	}
+	s.t.lock.Lock()
+	disabled := s.t.peerParams != nil && s.t.peerParams.DisableActiveMigration
+	s.t.lock.Unlock()
+	if disabled {
+		return errMigrationDisabled
+	}
+
+	tr := &quic.Transport{Conn: pc}
+	path, err := s.s.AddPath(tr)
+	if err != nil {
+		_ = tr.Close()
+		return err
+	}
+	s.keepTransport(tr)
+
+	ctx, cancel := context.WithTimeout(s.s.Context(), MigrationProbeTimeout)
+	defer cancel()
+	if err := path.Probe(ctx); err != nil {
+		_ = path.Close()
+		return err
+	}
+	if err := path.Switch(); err != nil {
+		_ = path.Close()
+		return err
+	}
+
+	s.lock.Lock()
+	s.pc = pc
+	s.lock.Unlock()
+	s.events.emit(Event{Type: EventMigration})
+	return nil
+}
+
+// keepTransport closes tr, which MigrateTo added a path on, once the
+// session ends. It cannot be closed before: quic-go keeps the session's
+// connection IDs registered on the transports of all its paths, also
+// after switching away or abandoning a path, and closing one of them
+// ends the session.
+func (s *Session) keepTransport(tr *quic.Transport) {
+	s.lock.Lock()
+	s.transports = append(s.transports, tr)
+	first := len(s.transports) == 1
+	s.lock.Unlock()
+	if !first {
+		return
+	}
+	go func() {
+		<-s.s.Context().Done()
+		s.lock.Lock()
+		transports := s.transports
+		s.transports = nil
+		s.lock.Unlock()
+		for _, tr := range transports {
+			_ = tr.Close()
+		}
+	}()
+}
+
+// packetConn returns the socket the session currently sends on.
+func (s *Session) packetConn() net.PacketConn {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.pc
+}
@@ -0,0 +1,17 @@
+package wrapper
+
+// SetName labels the session with a human-friendly name, e.g. the ID of
+// the user it belongs to, for use in logs and metrics. Unlike connection
+// IDs, the name is never sent to the peer.
+func (s *Session) SetName(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.name = name
+}
+
+// Name returns the name set by SetName.
+func (s *Session) Name() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.name
+}
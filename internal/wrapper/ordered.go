@@ -0,0 +1,266 @@
+package wrapper
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// orderedHeaderSize is the size of the sequence number and length
+// preceding each message.
+const orderedHeaderSize = 12
+
+// ErrReorderTimeout is returned by OrderedReader.ReadMessage when the next
+// message in sequence did not arrive in time while later ones did.
+var ErrReorderTimeout = errors.New("quic: timed out waiting for the next message in sequence")
+
+// ErrMessagesMissing is returned by OrderedReader.ReadMessage when all
+// streams finished while messages before the buffered ones never arrived.
+var ErrMessagesMissing = errors.New("quic: streams finished with messages missing")
+
+// OrderedWriter spreads messages over several streams while keeping a
+// global order that an OrderedReader on the other end restores. Each message
+// is prefixed with a 64 bit sequence number and a 32 bit length.
+type OrderedWriter struct {
+	maxSize int
+
+	lock    sync.Mutex
+	next    uint64
+	streams []*orderedStream
+}
+
+type orderedStream struct {
+	lock sync.Mutex
+	s    *Stream
+}
+
+// NewOrderedWriter creates an OrderedWriter writing to streams in turn.
+// Messages larger than maxSize are rejected with ErrMessageTooLarge; zero
+// or less selects DefaultMaxMessageSize. The limit cannot exceed the 32 bit
+// length.
+func NewOrderedWriter(maxSize int, streams ...*Stream) *OrderedWriter {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+	maxSize = int(min(uint64(maxSize), math.MaxUint32))
+	w := &OrderedWriter{maxSize: maxSize}
+	for _, s := range streams {
+		w.streams = append(w.streams, &orderedStream{s: s})
+	}
+	return w
+}
+
+// WriteMessage writes msg to the next stream. It is safe for concurrent use;
+// messages written concurrently are ordered by the call that got its
+// sequence number first.
+func (w *OrderedWriter) WriteMessage(msg []byte) error {
+	if len(msg) > w.maxSize {
+		return ErrMessageTooLarge
+	}
+
+	w.lock.Lock()
+	seq := w.next
+	w.next++
+	str := w.streams[seq%uint64(len(w.streams))]
+	w.lock.Unlock()
+
+	frame := make([]byte, orderedHeaderSize+len(msg))
+	binary.BigEndian.PutUint64(frame, seq)
+	binary.BigEndian.PutUint32(frame[8:], uint32(len(msg)))
+	copy(frame[orderedHeaderSize:], msg)
+
+	str.lock.Lock()
+	defer str.lock.Unlock()
+	_, err := str.s.write(frame)
+	return err
+}
+
+// Close finishes the write side of all streams.
+func (w *OrderedWriter) Close() error {
+	var err error
+	for _, str := range w.streams {
+		str.lock.Lock()
+		if cerr := str.s.CloseWrite(); err == nil {
+			err = cerr
+		}
+		str.lock.Unlock()
+	}
+	return err
+}
+
+// OrderedReader reads the messages of an OrderedWriter from several streams
+// and returns them in their original order.
+//
+// Messages that arrive ahead of their turn are held in a reorder buffer of
+// at most maxPending messages. When it is full, reading from the streams
+// pauses until the gap is filled, so a slow stream delays all others: the
+// order is paid for with head-of-line blocking across streams and with the
+// memory of the buffered messages.
+type OrderedReader struct {
+	maxPending int
+	maxSize    int
+	timeout    time.Duration
+
+	lock    sync.Mutex
+	changed chan struct{} // closed and replaced on every state change
+	next    uint64
+	pending map[uint64][]byte
+	open    int // streams still being read
+	err     error
+}
+
+// NewOrderedReader creates an OrderedReader reading from streams. At most
+// maxPending out-of-order messages are buffered, and ReadMessage waits at
+// most timeout for a missing message while later ones are buffered.
+// maxSize limits the size of a message like for NewMessageStream: a
+// larger one fails reading with ErrMessageTooLarge before its body is
+// read, and its stream is reset.
+func NewOrderedReader(maxPending, maxSize int, timeout time.Duration, streams ...*Stream) *OrderedReader {
+	if maxPending < 1 {
+		maxPending = 1
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+	r := &OrderedReader{
+		maxPending: maxPending,
+		maxSize:    maxSize,
+		timeout:    timeout,
+		changed:    make(chan struct{}),
+		pending:    make(map[uint64][]byte),
+		open:       len(streams),
+	}
+	for _, s := range streams {
+		go r.readLoop(s)
+	}
+	return r
+}
+
+// ReadMessage returns the next message in sequence. It returns io.EOF once
+// all streams finished and all messages were returned.
+//
+// A gap in the sequence is reported as a loss: ReadMessage returns
+// ErrReorderTimeout if it was not filled in time, and ErrMessagesMissing
+// if it cannot be filled anymore because all streams finished. Either way,
+// the reader skips the missing messages, so the next call returns the
+// first buffered message after the gap. Missing messages arriving later
+// are dropped.
+func (r *OrderedReader) ReadMessage() ([]byte, error) {
+	var timeout <-chan time.Time
+	for {
+		r.lock.Lock()
+		if msg, ok := r.pending[r.next]; ok {
+			delete(r.pending, r.next)
+			r.next++
+			r.notify()
+			r.lock.Unlock()
+			return msg, nil
+		}
+		if r.err != nil {
+			err := r.err
+			r.lock.Unlock()
+			return nil, err
+		}
+		if r.open == 0 {
+			err := io.EOF
+			if len(r.pending) > 0 {
+				r.skipGap()
+				err = ErrMessagesMissing
+			}
+			r.lock.Unlock()
+			return nil, err
+		}
+		if len(r.pending) > 0 && timeout == nil {
+			timer := time.NewTimer(r.timeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		changed := r.changed
+		r.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout:
+			r.lock.Lock()
+			if _, ok := r.pending[r.next]; !ok {
+				r.skipGap()
+				r.lock.Unlock()
+				return nil, ErrReorderTimeout
+			}
+			r.lock.Unlock() // the gap was filled in the meantime
+		}
+	}
+}
+
+// skipGap advances the next sequence number to the first buffered
+// message. The lock must be held.
+func (r *OrderedReader) skipGap() {
+	next := uint64(math.MaxUint64)
+	for seq := range r.pending {
+		next = min(next, seq)
+	}
+	r.next = next
+	r.notify()
+}
+
+func (r *OrderedReader) readLoop(s *Stream) {
+	err := r.readMessages(s)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.open--
+	if err != nil && !errors.Is(err, io.EOF) && r.err == nil {
+		r.err = err
+	}
+	r.notify()
+}
+
+func (r *OrderedReader) readMessages(s *Stream) error {
+	var header [orderedHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(s, header[:]); err != nil {
+			return err
+		}
+		seq := binary.BigEndian.Uint64(header[:])
+		size := binary.BigEndian.Uint32(header[8:])
+		if uint64(size) > uint64(r.maxSize) {
+			s.cancel(errorCodeCanceled)
+			return ErrMessageTooLarge
+		}
+
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(s, msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		r.lock.Lock()
+		// The next expected message is always taken, so a full buffer
+		// cannot deadlock the stream that carries it.
+		for seq != r.next && len(r.pending) >= r.maxPending && r.err == nil {
+			changed := r.changed
+			r.lock.Unlock()
+			<-changed
+			r.lock.Lock()
+		}
+		if seq < r.next {
+			// skipped by ReadMessage as lost
+			r.lock.Unlock()
+			continue
+		}
+		r.pending[seq] = msg
+		r.notify()
+		r.lock.Unlock()
+	}
+}
+
+// notify wakes up all waiters. The lock must be held.
+func (r *OrderedReader) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
@@ -0,0 +1,130 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrdered_RoundTrip(t *testing.T) {
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+	var streams []*Stream
+	for i := 0; i < 2; i++ {
+		str, err := client.OpenStream()
+		if !assert.NoError(t, err) {
+			return
+		}
+		streams = append(streams, str)
+	}
+	w := NewOrderedWriter(0, streams...)
+	for _, msg := range []string{"a", "b", "c"} {
+		assert.NoError(t, w.WriteMessage([]byte(msg)))
+	}
+	assert.NoError(t, w.Close())
+
+	var accepted []*Stream
+	for i := 0; i < 2; i++ {
+		str, err := server.AcceptStream()
+		if !assert.NoError(t, err) {
+			return
+		}
+		accepted = append(accepted, str)
+	}
+	r := NewOrderedReader(4, 0, time.Second, accepted...)
+	for _, want := range []string{"a", "b", "c"} {
+		msg, err := r.ReadMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(msg))
+	}
+}
+
+func TestOrdered_TooLarge(t *testing.T) {
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+	str, err := client.OpenStream()
+	assert.NoError(t, err)
+	assert.ErrorIs(t, NewOrderedWriter(1024, str).WriteMessage(make([]byte, 1025)), ErrMessageTooLarge)
+
+	// announce a 4 GB message with sequence number 0 without sending it
+	header := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}
+	_, err = str.Write(header, false)
+	assert.NoError(t, err)
+
+	accepted, err := server.AcceptStream()
+	assert.NoError(t, err)
+	_, err = NewOrderedReader(1, 1024, time.Second, accepted).ReadMessage()
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+
+	// the stream was reset
+	_, err = str.Read(make([]byte, 1))
+	var streamErr *quic.StreamError
+	if assert.True(t, errors.As(err, &streamErr)) {
+		assert.Equal(t, errorCodeCanceled, streamErr.ErrorCode)
+	}
+}
+
+func TestOrderedReader_Gap(t *testing.T) {
+	testCases := []struct {
+		name string
+		// finish finishes the stream after the messages were sent.
+		finish bool
+		err    error
+	}{
+		{"Timeout", false, ErrReorderTimeout},
+		{"StreamsFinished", true, ErrMessagesMissing},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+			str, err := client.OpenStream()
+			if !assert.NoError(t, err) {
+				return
+			}
+			// messages 1 and 2, 0 is lost
+			for seq, msg := range map[uint64]string{1: "b", 2: "c"} {
+				frame := make([]byte, orderedHeaderSize+len(msg))
+				binary.BigEndian.PutUint64(frame, seq)
+				binary.BigEndian.PutUint32(frame[8:], uint32(len(msg)))
+				copy(frame[orderedHeaderSize:], msg)
+				_, err := str.Write(frame, false)
+				assert.NoError(t, err)
+			}
+			if tc.finish {
+				assert.NoError(t, str.Close())
+			} else {
+				assert.NoError(t, str.Flush())
+			}
+
+			accepted, err := server.AcceptStream()
+			if !assert.NoError(t, err) {
+				return
+			}
+			r := NewOrderedReader(4, 0, 100*time.Millisecond, accepted)
+			_, err = r.ReadMessage()
+			assert.ErrorIs(t, err, tc.err)
+
+			// the reader continues after the gap
+			for _, want := range []string{"b", "c"} {
+				msg, err := r.ReadMessage()
+				assert.NoError(t, err)
+				assert.Equal(t, want, string(msg))
+			}
+			if tc.finish {
+				_, err = r.ReadMessage()
+				assert.ErrorIs(t, err, io.EOF)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package wrapper
+
+import "net"
+
+// OnPathChange registers f to be called when the peer's address changes,
+// e.g. when a NAT rebinding gives a mobile client a new port. RemoteAddr
+// returns the new address from then on.
+//
+// quic-go switches to a new client address on the server once it was
+// validated, but neither tells the application nor traces the switch. The
+// change is therefore detected by comparing the address on each packet
+// quic-go traces, which is the first packet sent or received after the
+// switch. Only servers see such changes: in QUIC only clients migrate, and
+// a client's own migration with MigrateTo changes its local address,
+// reported as EventMigration. f is called on quic-go's connection
+// goroutine and must not block.
+func (s *Session) OnPathChange(f func(old, new net.Addr)) {
+	s.pathLock.Lock()
+	defer s.pathLock.Unlock()
+	if s.pathChange == nil {
+		s.remoteAddr = s.s.RemoteAddr()
+		check := s.checkPath
+		s.t.pathCheck.Store(&check)
+	}
+	s.pathChange = append(s.pathChange, f)
+}
+
+// RemoteAddr returns the peer's current address.
+func (s *Session) RemoteAddr() net.Addr {
+	return s.s.RemoteAddr()
+}
+
+// checkPath calls the OnPathChange callbacks if the peer's address changed.
+func (s *Session) checkPath() {
+	addr := s.s.RemoteAddr()
+
+	s.pathLock.Lock()
+	old := s.remoteAddr
+	if old.Network() == addr.Network() && old.String() == addr.String() {
+		s.pathLock.Unlock()
+		return
+	}
+	s.remoteAddr = addr
+	callbacks := s.pathChange
+	s.pathLock.Unlock()
+
+	for _, f := range callbacks {
+		f(old, addr)
+	}
+}
+
+// checkPath runs the path check of OnPathChange, if registered.
+func (t *tracer) checkPath() {
+	if check := t.pathCheck.Load(); check != nil {
+		(*check)()
+	}
+}
@@ -0,0 +1,43 @@
+package wrapper
+
+import (
+	"sync/atomic"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// streamCounts holds the number of streams of each of the four stream
+// types, indexed by the two lowest bits of their IDs.
+type streamCounts [4]atomic.Int64
+
+// add records stream id, which implies all streams of its type with lower
+// IDs, as QUIC opens streams in order.
+func (c *streamCounts) add(id quic.StreamID) {
+	n := int64(id>>2) + 1
+	v := &c[id&3]
+	for {
+		old := v.Load()
+		if n <= old || v.CompareAndSwap(old, n) {
+			return
+		}
+	}
+}
+
+// PendingStreams returns the number of streams, bidirectional and
+// unidirectional, the peer opened that were not accepted yet, so a server
+// can shed load before accepting more. No streams are buffered by the
+// wrapper for this; the number is derived from the stream IDs of the
+// frames received so far, so it counts a stream as soon as its first frame
+// arrived. Streams that were finished or reset before being accepted are
+// included, as they still have to be accepted.
+func (s *Session) PendingStreams() int {
+	bidi, uni := quic.StreamID(0), quic.StreamID(2)
+	if s.client {
+		bidi, uni = 1, 3 // opened by the server
+	}
+	pending := 0
+	for _, typ := range []quic.StreamID{bidi, uni} {
+		pending += max(0, int(s.t.peerStreams[typ].Load()-s.accepted[typ].Load()))
+	}
+	return pending
+}
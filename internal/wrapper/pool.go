@@ -0,0 +1,248 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned by Pool.Get after the pool was closed.
+var ErrPoolClosed = errors.New("quic: pool closed")
+
+// Backoff of dials retried after a failure, by a Pool or DialWithRetry.
+const (
+	minDialRetry = 100 * time.Millisecond
+	maxDialRetry = 5 * time.Second
+)
+
+// poolDialTimeout bounds a background dial of a Pool, like Dial.
+const poolDialTimeout = 10 * time.Second
+
+// Pool keeps a number of established sessions to a server ready for use,
+// saving the handshake when a session is needed.
+type Pool struct {
+	addr   string
+	config *Config
+	size   int
+
+	ctx    context.Context // canceled by Close
+	cancel context.CancelFunc
+	dials  sync.WaitGroup
+
+	lock    sync.Mutex
+	idle    []*Session
+	active  map[*Session]struct{}
+	dialing int
+	retry   time.Duration // backoff after the last failed dial, zero after a success
+	closed  bool
+	wake    chan struct{}
+}
+
+// PoolStats holds the numbers of sessions of a Pool.
+type PoolStats struct {
+	// Idle is the number of established sessions ready to be handed out.
+	Idle int
+	// Active is the number of sessions handed out by Get and not returned
+	// or closed yet.
+	Active int
+	// Dialing is the number of sessions being established in the background.
+	Dialing int
+}
+
+// NewPool creates a Pool keeping size idle sessions to addr, dialed with
+// config in the background. A size below one is treated as one. Idle
+// sessions that die, e.g. by the idle timeout, are replaced; failed dials
+// are retried with a backoff of up to 5 seconds. As every session owns
+// its socket, config must not bind a fixed local port with LocalAddr.
+func NewPool(addr string, config *Config, size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		addr:   addr,
+		config: config,
+		size:   size,
+		ctx:    ctx,
+		cancel: cancel,
+		active: make(map[*Session]struct{}),
+		wake:   make(chan struct{}, 1),
+	}
+	go p.maintain()
+	return p
+}
+
+// Get hands out an idle session, which the caller owns until it returns
+// it with Put or closes it. If no session is idle, Get dials a new one,
+// aborting when ctx is done. The pool starts replacing the session handed
+// out right away.
+func (p *Pool) Get(ctx context.Context) (*Session, error) {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return nil, ErrPoolClosed
+	}
+	if len(p.idle) > 0 {
+		s := p.idle[0]
+		p.idle = p.idle[1:]
+		p.active[s] = struct{}{}
+		p.lock.Unlock()
+		p.notify()
+		return s, nil
+	}
+	p.lock.Unlock()
+
+	s, err := DialContext(ctx, p.addr, p.config)
+	if err != nil {
+		return nil, err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.active[s] = struct{}{}
+	p.watch(s)
+	return s, nil
+}
+
+// Put returns a session handed out by Get. It is kept for reuse if it is
+// still open and the pool lacks idle sessions, and closed otherwise.
+func (p *Pool) Put(s *Session) {
+	p.lock.Lock()
+	delete(p.active, s)
+	keep := !p.closed && len(p.idle) < p.size && s.s.Context().Err() == nil
+	if keep {
+		p.idle = append(p.idle, s)
+	}
+	p.lock.Unlock()
+
+	if !keep {
+		_ = s.Close()
+	}
+}
+
+// RoundTrip sends request like Session.RoundTrip on a session of the pool,
+// which is returned with Put afterwards. Unlike Session.RoundTripOpts, an
+// idempotent request is also retried on another session if its session
+// ended during the request; see RoundTripOptions.
+func (p *Pool) RoundTrip(ctx context.Context, request []byte, opts RoundTripOptions) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		s, err := p.Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		response, err := s.RoundTrip(ctx, request)
+		ended := s.s.Context().Err() != nil
+		p.Put(s)
+		if err == nil || ctx.Err() != nil || !opts.retry(attempt) || !(ended || isStreamReset(err)) {
+			return response, err
+		}
+	}
+}
+
+// Stats returns the current numbers of sessions of the pool.
+func (p *Pool) Stats() PoolStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return PoolStats{Idle: len(p.idle), Active: len(p.active), Dialing: p.dialing}
+}
+
+// Close stops dialing, waits for the dials in progress to end and closes
+// the idle sessions. Sessions handed out stay open; Put closes them.
+func (p *Pool) Close() error {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.lock.Unlock()
+
+	p.cancel()
+	p.dials.Wait()
+
+	p.lock.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.lock.Unlock()
+
+	var err error
+	for _, s := range idle {
+		if cerr := s.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// notify wakes up the maintain loop.
+func (p *Pool) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// maintain dials sessions until the pool has size idle ones, until the
+// pool is closed.
+func (p *Pool) maintain() {
+	for {
+		p.lock.Lock()
+		if p.closed {
+			p.lock.Unlock()
+			return
+		}
+		for n := p.size - len(p.idle) - p.dialing; n > 0; n-- {
+			p.dialing++
+			p.dials.Add(1)
+			go p.dial()
+		}
+		p.lock.Unlock()
+
+		select {
+		case <-p.wake:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// dial establishes an idle session in the background.
+func (p *Pool) dial() {
+	defer p.dials.Done()
+
+	ctx, cancel := context.WithTimeout(p.ctx, poolDialTimeout)
+	defer cancel()
+	s, err := DialContext(ctx, p.addr, p.config)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.dialing--
+	if err != nil {
+		p.retry = min(max(2*p.retry, minDialRetry), maxDialRetry)
+		time.AfterFunc(p.retry, p.notify)
+		return
+	}
+	p.retry = 0
+	if p.closed {
+		go func() { _ = s.Close() }()
+		return
+	}
+	p.idle = append(p.idle, s)
+	p.watch(s)
+}
+
+// watch forgets s once it is closed. The lock must be held.
+func (p *Pool) watch(s *Session) {
+	context.AfterFunc(s.s.Context(), func() {
+		p.lock.Lock()
+		delete(p.active, s)
+		for i, idle := range p.idle {
+			if idle == s {
+				p.idle = append(p.idle[:i], p.idle[i+1:]...)
+				break
+			}
+		}
+		p.lock.Unlock()
+		p.notify()
+	})
+}
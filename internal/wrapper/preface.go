@@ -0,0 +1,48 @@
+package wrapper
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ErrPrefaceMismatch is returned by ReadPreface if the stream does not
+// start with the expected preface.
+var ErrPrefaceMismatch = errors.New("quic: stream does not start with the expected preface")
+
+// prepareStream writes the session's preface to the first stream opened.
+//
+// The preface is stream data, so it is only sent once the handshake
+// completed and is encrypted like any other data. It identifies the
+// protocol to the server application, e.g. to pick a handler; middleboxes
+// in front of the server cannot observe it.
+func (s *Session) prepareStream(str *Stream) (*Stream, error) {
+	var err error
+	s.prefaceOnce.Do(func() {
+		if len(s.preface) > 0 {
+			_, err = str.write(s.preface)
+		}
+	})
+	if err != nil {
+		str.cancel(errorCodeCanceled)
+		return nil, err
+	}
+	return str, nil
+}
+
+// ReadPreface reads len(preface) bytes from str and verifies that they
+// match preface. It is used by servers on the first stream accepted from
+// a client configured with Config.Preface, before reading the stream's
+// data, which may be the client's first RoundTrip request. The stream is
+// reset if the preface does not match.
+func ReadPreface(str *Stream, preface []byte) error {
+	buf := make([]byte, len(preface))
+	if _, err := io.ReadFull(str, buf); err != nil {
+		return err
+	}
+	if !bytes.Equal(buf, preface) {
+		str.cancel(errorCodeCanceled)
+		return ErrPrefaceMismatch
+	}
+	return nil
+}
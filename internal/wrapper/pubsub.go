@@ -0,0 +1,145 @@
+package wrapper
+
+import (
+	"errors"
+	"sync"
+)
+
+// MaxTopicLength is the maximum length of a topic in bytes.
+const MaxTopicLength = 255
+
+// subscriptionBuffer is the number of messages queued per subscription.
+const subscriptionBuffer = 16
+
+var errTopicTooLong = errors.New("quic: topic exceeds maximum length")
+
+// pubsub dispatches the datagrams of a session to the subscriptions of
+// their topic.
+type pubsub struct {
+	lock   sync.Mutex
+	subs   map[string]map[*subscription]struct{}
+	closed bool
+}
+
+type subscription struct {
+	ch chan []byte
+}
+
+// Publish sends msg to the peer's subscribers of topic as a single
+// datagram, see SendDatagram. Delivery is unreliable and unordered like
+// for any datagram, and messages for topics without subscribers are
+// dropped by the peer.
+//
+// Each datagram starts with a header of one byte holding the length of the
+// topic followed by the topic, so a message may be up to 1+len(topic)
+// bytes shorter than a raw datagram. For a message that is too large, the
+// returned *quic.DatagramTooLargeError tells the maximum message size for
+// topic, i.e. with the header already subtracted.
+func (s *Session) Publish(topic string, msg []byte) error {
+	if len(topic) > MaxTopicLength {
+		return errTopicTooLong
+	}
+	header := make([]byte, 1, 1+len(topic))
+	header[0] = byte(len(topic))
+	return s.sendDatagram(append(header, topic...), msg)
+}
+
+// Subscribe returns a channel receiving the messages the peer publishes
+// to topic, and a function ending the subscription and closing the
+// channel. All channels are closed when the session ends. A topic may
+// have several subscriptions, all of which receive every message; they
+// share its bytes, which must not be modified.
+//
+// The first call starts consuming all datagrams of the session, so
+// ReceiveDatagram must not be used once the session subscribed to a
+// topic; datagrams without a valid topic header are dropped. Each
+// subscription buffers 16 messages, further messages are dropped until
+// the channel is read, so a slow subscriber never holds up the others.
+func (s *Session) Subscribe(topic string) (<-chan []byte, func()) {
+	sub := &subscription{ch: make(chan []byte, subscriptionBuffer)}
+	ps := s.startPubsub()
+
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	if ps.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	if ps.subs[topic] == nil {
+		ps.subs[topic] = make(map[*subscription]struct{})
+	}
+	ps.subs[topic][sub] = struct{}{}
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() { ps.unsubscribe(topic, sub) })
+	}
+}
+
+// startPubsub creates the session's pubsub and starts dispatching
+// datagrams, unless done already.
+func (s *Session) startPubsub() *pubsub {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.pubsub == nil {
+		s.pubsub = &pubsub{subs: make(map[string]map[*subscription]struct{})}
+		go s.dispatchDatagrams(s.pubsub)
+	}
+	return s.pubsub
+}
+
+// dispatchDatagrams delivers the received datagrams to the subscriptions
+// until the session ends or does not support datagrams.
+func (s *Session) dispatchDatagrams(ps *pubsub) {
+	defer ps.close()
+	for {
+		p, err := s.receiveDatagram(s.s.Context())
+		if err != nil {
+			return
+		}
+		if len(p) == 0 || len(p) < 1+int(p[0]) {
+			continue
+		}
+		header := 1 + int(p[0])
+		msg := p[header:]
+		s.totals.addReceived(len(msg))
+		ps.deliver(string(p[1:header]), msg)
+	}
+}
+
+func (ps *pubsub) deliver(topic string, msg []byte) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	for sub := range ps.subs[topic] {
+		select {
+		case sub.ch <- msg:
+		default:
+		}
+	}
+}
+
+func (ps *pubsub) unsubscribe(topic string, sub *subscription) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	if _, ok := ps.subs[topic][sub]; !ok {
+		return // closed with the session
+	}
+	delete(ps.subs[topic], sub)
+	if len(ps.subs[topic]) == 0 {
+		delete(ps.subs, topic)
+	}
+	close(sub.ch)
+}
+
+// close ends all subscriptions.
+func (ps *pubsub) close() {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	ps.closed = true
+	for topic, subs := range ps.subs {
+		for sub := range subs {
+			close(sub.ch)
+		}
+		delete(ps.subs, topic)
+	}
+}
@@ -8,9 +8,13 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"io"
 	"net"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/quic-go/quic-go"
@@ -21,6 +25,101 @@ type Config struct {
 	Certificate *x509.Certificate
 	PrivateKey  crypto.PrivateKey
 	SkipVerify  bool
+
+	// Certificates are offered in addition to Certificate, which may then
+	// be nil. A server presents the first one the client supports, a client
+	// uses the first one.
+	Certificates []tls.Certificate
+
+	// TLSConfigModifier is called with the tls.Config built by the wrapper
+	// right before it is used. MinVersion is restored to TLS 1.3
+	// afterwards, as QUIC requires it.
+	TLSConfigModifier func(*tls.Config)
+
+	// QUICConfigModifier is called last with the quic.Config built by the
+	// wrapper and can override any default. A Tracer it sets is combined
+	// with the wrapper's own tracer.
+	QUICConfigModifier func(*quic.Config)
+
+	// Preface, if set, is written by the client at the start of the first
+	// bidirectional stream it opens, whether by OpenStream, RoundTrip or
+	// Upload, so it precedes a first request too. See ReadPreface.
+	Preface []byte
+
+	// StreamIdleTimeout, if non-zero, resets bidirectional streams with
+	// stream error code 2 once they had no reads or writes for this long.
+	StreamIdleTimeout time.Duration
+
+	// AcceptBacklog, if non-zero, bounds the incoming connections a
+	// Listener holds that are handshaking or not accepted yet. Further
+	// ones are refused with CONNECTION_REFUSED.
+	AcceptBacklog int
+
+	// HandshakeRateLimit, if non-zero, is the number of handshakes per
+	// second a Listener starts, with bursts of up to one second worth, see
+	// Listener.RateLimitedHandshakes.
+	HandshakeRateLimit float64
+
+	// MaxConcurrentHandshakes, if non-zero, bounds the handshakes a
+	// Listener runs at once, see Listener.HandshakesInFlight.
+	MaxConcurrentHandshakes int
+
+	// EventBufferSize, if non-zero, enables Session.Events with a buffer
+	// of that many events.
+	EventBufferSize int
+
+	// OnSocketBufferWarning, if set, is called with the sizes in bytes when
+	// the receive buffer of a socket created by Listen or DialContext, or
+	// passed to ListenFile, could not be raised to the size quic-go
+	// recommends.
+	OnSocketBufferWarning func(current, recommended int)
+
+	// MaxSessionMemory, if non-zero, caps the received but unread stream
+	// data in bytes a session holds, see Session.MemoryUsage. A session
+	// exceeding it is closed with application error code 1.
+	MaxSessionMemory int
+
+	// EnableDatagrams offers QUIC datagrams (RFC 9221) to the peer, see
+	// Session.SendDatagram.
+	EnableDatagrams bool
+
+	// LocalAddr, if set, is the address the socket created by Dial and
+	// DialContext is bound to; if nil, the system picks an ephemeral port.
+	// To dial several sessions from one port, share a socket with
+	// NewTransport.
+	LocalAddr *net.UDPAddr
+
+	// DatagramReceipts enables datagrams and Session.SendDatagramWithReceipt.
+	// Every datagram then starts with a byte telling its type, so both
+	// peers must set it.
+	DatagramReceipts bool
+
+	// MaxHandshakeMessageSize, if non-zero, fails the handshake with a
+	// bad_certificate alert if the peer's certificate chain is larger than
+	// this many bytes in DER encoding.
+	MaxHandshakeMessageSize int
+
+	// EarlyAccept makes a Listener hand out sessions one round trip before
+	// the handshake completes, so data the server writes is sent as
+	// 0.5-RTT data. Until Session.HandshakeComplete is closed, the client
+	// is not authenticated.
+	EarlyAccept bool
+
+	// DisableECN stops quic-go from marking sent packets as ECN-capable
+	// and from reading the marks of received ones, see Capabilities.
+	DisableECN bool
+
+	// DatagramByteRate and DatagramPacketRate, if non-zero, limit the
+	// datagrams a session sends to that many payload bytes and datagrams
+	// per second, with bursts of up to one second worth. Excess datagrams
+	// are dropped with ErrDatagramRateLimited.
+	DatagramByteRate   float64
+	DatagramPacketRate float64
+
+	// DatagramRateBlock makes sending a datagram exceeding the rate limit
+	// wait until the limit allows it or the session ends, see
+	// Session.ThrottledDatagrams.
+	DatagramRateBlock bool
 }
 
 func getDefaultQuicConfig() *quic.Config {
@@ -33,11 +132,35 @@ func getDefaultQuicConfig() *quic.Config {
 	}
 }
 
+// getQuicConfig merges the defaults with the modifications of config.
+func getQuicConfig(config *Config, tracer tracerFunc) *quic.Config {
+	quicConfig := getDefaultQuicConfig()
+	quicConfig.EnableDatagrams = config.EnableDatagrams || config.DatagramReceipts
+	if config.QUICConfigModifier != nil {
+		config.QUICConfigModifier(quicConfig)
+	}
+	if config.MaxSessionMemory > 0 {
+		limitReceiveWindows(quicConfig, config.MaxSessionMemory)
+	}
+	quicConfig.Tracer = combineTracers(tracer, quicConfig.Tracer)
+	return quicConfig
+}
+
 var errClientWithoutRemoteAddress = errors.New("quic: creating client without remote address")
 
-// Client establishes a QUIC session over an existing conn
+// Client establishes a QUIC session over an existing conn. For a
+// *net.UDPConn, ClientUDP avoids adapting it.
 func Client(conn net.Conn, config *Config) (*Session, error) {
-	rAddr := conn.RemoteAddr()
+	return ClientWithRemote(conn, conn.RemoteAddr(), config)
+}
+
+// ClientWithRemote establishes a QUIC session over conn like Client, using
+// rAddr as the address of the peer. It supports conns that do not expose
+// their remote address, such as some tunnels and pipes.
+func ClientWithRemote(conn net.Conn, rAddr net.Addr, config *Config) (*Session, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 	if rAddr == nil {
 		return nil, errClientWithoutRemoteAddress
 	}
@@ -45,11 +168,17 @@ func Client(conn net.Conn, config *Config) (*Session, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	s, err := quic.Dial(ctx, newFakePacketConn(conn), rAddr, getTLSConfig(config), getDefaultQuicConfig())
+	t := newTracer(config)
+	quicConfig := getQuicConfig(config, newClientTracer(t))
+
+	pc := newFakePacketConn(conn, rAddr)
+	s, err := quic.Dial(ctx, pc, rAddr, getClientTLSConfig(config, t), quicConfig)
 	if err != nil {
-		return nil, err
+		return nil, versionNegotiationError(err)
 	}
-	return &Session{s: s}, nil
+	session := newSession(s, t, true, config)
+	session.pc = pc
+	return session, nil
 }
 
 // Dial dials the address over quic
@@ -57,97 +186,357 @@ func Dial(addr string, config *Config) (*Session, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	s, err := quic.DialAddr(ctx, addr, getTLSConfig(config), getDefaultQuicConfig())
+	return DialContext(ctx, addr, config)
+}
+
+// DialContext dials the address over quic. The handshake is aborted when ctx is done.
+func DialContext(ctx context.Context, addr string, config *Config) (*Session, error) {
+	lAddr := config.LocalAddr
+	if lAddr == nil {
+		lAddr = &net.UDPAddr{IP: net.IPv4zero, Port: 0}
+	}
+	pc, err := net.ListenUDP("udp", lAddr)
 	if err != nil {
 		return nil, err
 	}
+	checkReceiveBuffer(pc, config)
 
-	return &Session{s: s}, nil
+	session, err := dialAddr(ctx, addr, config, func(ctx context.Context, rAddr net.Addr, tlsConfig *tls.Config, quicConfig *quic.Config) (*quic.Conn, error) {
+		return quic.Dial(ctx, quicSocket(pc, config), rAddr, tlsConfig, quicConfig)
+	})
+	if err != nil {
+		if cerr := pc.Close(); cerr != nil {
+			err = fmt.Errorf("failed to close socket (%s) after dial failed: %w", cerr, err)
+		}
+		return nil, err
+	}
+
+	session.pc = pc
+	go func() {
+		// The socket is owned by the session.
+		<-session.s.Context().Done()
+		_ = pc.Close()
+	}()
+	return session, nil
+}
+
+type dialFunc func(context.Context, net.Addr, *tls.Config, *quic.Config) (*quic.Conn, error)
+
+// dialAddr resolves addr and establishes a session using dial.
+func dialAddr(ctx context.Context, addr string, config *Config, dial dialFunc) (*Session, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	rAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTracer(config)
+	tlsConfig := getClientTLSConfig(config, t)
+	if host, _, splitErr := net.SplitHostPort(addr); splitErr == nil {
+		tlsConfig.ServerName = host
+	}
+
+	quicConfig := getQuicConfig(config, newClientTracer(t))
+
+	s, err := dial(ctx, rAddr, tlsConfig, quicConfig)
+	if err != nil {
+		return nil, versionNegotiationError(err)
+	}
+	return newSession(s, t, true, config), nil
 }
 
-// Server creates a listener for listens for incoming QUIC sessions
+// Server creates a listener for listens for incoming QUIC sessions. For a
+// *net.UDPConn, ServerUDP avoids adapting it.
 func Server(conn net.Conn, config *Config) (*Listener, error) {
-	l, err := quic.Listen(newFakePacketConn(conn), getTLSConfig(config), getDefaultQuicConfig())
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	tracers := newTracerRegistry(config)
+	quicConfig := getQuicConfig(config, tracers.newTracer)
+	tracers.limitIncoming(quicConfig, config)
+
+	pc := newFakePacketConn(conn, conn.RemoteAddr())
+	l, err := listen(pc, getTLSConfig(config), quicConfig, config, tracers)
 	if err != nil {
 		return nil, err
 	}
-	return &Listener{l: l}, nil
+	return newListener(l, tracers, pc, false, config), nil
 }
 
 // Listen listens on the address over quic
 func Listen(addr string, config *Config) (*Listener, error) {
-	l, err := quic.ListenAddr(addr, getTLSConfig(config), getDefaultQuicConfig())
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	tracers := newTracerRegistry(config)
+	quicConfig := getQuicConfig(config, tracers.newTracer)
+	tracers.limitIncoming(quicConfig, config)
+
+	lAddr, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
 		return nil, err
 	}
-	return &Listener{l: l}, nil
+
+	pc, err := net.ListenUDP("udp", lAddr)
+	if err != nil {
+		return nil, err
+	}
+	checkReceiveBuffer(pc, config)
+
+	l, err := listen(quicSocket(pc, config), getTLSConfig(config), quicConfig, config, tracers)
+	if err != nil {
+		if cerr := pc.Close(); cerr != nil {
+			err = fmt.Errorf("failed to close socket (%s) after listen failed: %w", cerr, err)
+		}
+		return nil, err
+	}
+	return newListener(l, tracers, pc, true, config), nil
 }
 
+// getTLSConfig builds the tls.Config of config. The TLSConfigModifier is
+// responsible for the security of its changes; only MinVersion is
+// restored afterwards.
 func getTLSConfig(config *Config) *tls.Config {
 	/* #nosec G402 */
-	return &tls.Config{
+	tlsConfig := &tls.Config{
 		MinVersion:         tls.VersionTLS13,
 		InsecureSkipVerify: config.SkipVerify,
 		ClientAuth:         tls.RequireAnyClientCert,
-		Certificates: []tls.Certificate{{
+		NextProtos:         []string{"pion-quic"},
+	}
+	if config.Certificate != nil {
+		tlsConfig.Certificates = append(tlsConfig.Certificates, tls.Certificate{
 			Certificate: [][]byte{config.Certificate.Raw},
 			PrivateKey:  config.PrivateKey,
-		}},
-		NextProtos: []string{"pion-quic"},
+		})
+	}
+	tlsConfig.Certificates = append(tlsConfig.Certificates, config.Certificates...)
+	if config.TLSConfigModifier != nil {
+		config.TLSConfigModifier(tlsConfig)
+		if tlsConfig.MinVersion < tls.VersionTLS13 {
+			tlsConfig.MinVersion = tls.VersionTLS13
+		}
+	}
+	if config.MaxHandshakeMessageSize > 0 {
+		limitHandshakeMessages(tlsConfig, config.MaxHandshakeMessageSize)
+	}
+	return tlsConfig
+}
+
+// getClientTLSConfig returns the TLS config of a client session, recording
+// whether the server asked for the client's certificate.
+func getClientTLSConfig(config *Config, t *tracer) *tls.Config {
+	tlsConfig := getTLSConfig(config)
+	certificates := tlsConfig.Certificates
+	getClientCertificate := tlsConfig.GetClientCertificate
+	tlsConfig.GetClientCertificate = func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		t.lock.Lock()
+		t.clientAuth = true
+		t.lock.Unlock()
+		if getClientCertificate != nil {
+			return getClientCertificate(info)
+		}
+		if len(certificates) == 0 {
+			return &tls.Certificate{}, nil
+		}
+		return &certificates[0], nil
 	}
+	t.offeredProtocols = slices.Clone(tlsConfig.NextProtos)
+	return tlsConfig
 }
 
 // A Session is a QUIC connection between two peers.
 type Session struct {
-	s *quic.Conn
+	s      *quic.Conn
+	t      *tracer
+	pc     net.PacketConn
+	client bool
+
+	lock       sync.Mutex
+	requests   chan struct{}     // semaphore bounding concurrent requests
+	transports []*quic.Transport // created by MigrateTo, closed once the session ends
+
+	preface     []byte
+	prefaceOnce sync.Once
+
+	idleTimeout time.Duration
+	streams     map[*Stream]struct{} // tracked for idleTimeout
+
+	acceptDeadline atomic.Int64 // time.Duration, see AcceptStreamDeadline
+
+	totals   byteTotals
+	sched    *scheduler
+	accepted streamCounts // streams accepted from the peer
+
+	name string
+
+	events *eventQueue // nil unless enabled by Config.EventBufferSize
+
+	pathLock   sync.Mutex
+	remoteAddr net.Addr // as last seen by checkPath
+	pathChange []func(old, new net.Addr)
+
+	pubsub   *pubsub           // created by the first Subscribe
+	receipts *datagramReceipts // nil unless enabled by Config.DatagramReceipts
+	limiter  *datagramLimiter  // nil unless a datagram rate is configured
+}
+
+func newSession(conn *quic.Conn, t *tracer, client bool, config *Config) *Session {
+	s := &Session{s: conn, t: t, client: client, idleTimeout: config.StreamIdleTimeout, sched: newScheduler()}
+	if client {
+		s.preface = config.Preface
+	}
+	if s.idleTimeout > 0 {
+		go s.sweepIdleStreams()
+	}
+	if config.EventBufferSize > 0 {
+		s.startEvents(config.EventBufferSize)
+	}
+	s.limiter = newDatagramLimiter(config)
+	if config.DatagramReceipts {
+		s.receipts = newDatagramReceipts()
+		go s.readDatagrams()
+		go s.sendReceipts()
+	}
+	if m := t.mem; m != nil {
+		m.lock.Lock()
+		m.exceeded = func() {
+			go func() { _ = s.CloseWithError(errorCodeMemoryLimit, errMemoryLimit) }()
+		}
+		m.lock.Unlock()
+	}
+	return s
+}
+
+// wrapStream wraps a bidirectional stream of the session.
+func (s *Session) wrapStream(str *quic.Stream, opts StreamOptions) *Stream {
+	stream := newStream(str, opts)
+	stream.totals = &s.totals
+	stream.mem = s.t.mem
+	stream.client = s.client
+	stream.progress = s.t.trackSending(str.Context(), str.StreamID())
+	stream.useScheduler(s.sched)
+	if opts.IdleTimeout > 0 {
+		code := errorCodeIdle
+		if opts.IdleResetCode != 0 {
+			code = quic.StreamErrorCode(opts.IdleResetCode)
+		}
+		stream.startIdleTimer(opts.IdleTimeout, code)
+	}
+	if !isLocalStream(str.StreamID(), s.client) {
+		s.accepted.add(str.StreamID())
+	}
+	s.streamEvent(str.StreamID())
+	return s.trackStream(stream)
 }
 
-// OpenStream opens a new stream
+// wrapWritableStream wraps a unidirectional stream of the session.
+func (s *Session) wrapWritableStream(str *quic.SendStream, opts StreamOptions) *WritableStream {
+	stream := newWritableStream(str, opts)
+	stream.totals = &s.totals
+	stream.tracer = s.t
+	if opts.TrackAcks {
+		stream.acks = s.t.watchStream(str)
+		stream.session = s.s.Context()
+	}
+	stream.progress = s.t.trackSending(str.Context(), str.StreamID())
+	stream.useScheduler(s.sched, opts.Priority)
+	s.streamEvent(str.StreamID())
+	return stream
+}
+
+// OpenStream opens a new stream without blocking. If the peer's stream
+// limit is reached, it fails right away with a *quic.StreamLimitReachedError;
+// calls succeed again once the peer raised the limit with a MAX_STREAMS
+// frame, which it sends as its streams complete. Use OpenStreamOpts to wait
+// for that instead.
 func (s *Session) OpenStream() (*Stream, error) {
 	str, err := s.s.OpenStream()
 	if err != nil {
 		return nil, err
 	}
-	return &Stream{s: str}, nil
+	return s.prepareStream(s.wrapStream(str, StreamOptions{}))
+}
+
+// OpenStreamOpts opens a new stream tuned by opts. Unlike OpenStream it
+// blocks while the peer's stream limit is reached, until the peer raises
+// the limit or ctx is done. With opts.SendBufferSize, written data stays
+// buffered until the stream is flushed or closed.
+func (s *Session) OpenStreamOpts(ctx context.Context, opts StreamOptions) (*Stream, error) {
+	str, err := s.s.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.prepareStream(s.wrapStream(str, opts))
 }
 
-// OpenUniStream opens and returns a new WritableStream
+// OpenUniStream opens and returns a new WritableStream. Like OpenStream it
+// does not block, failing with a *quic.StreamLimitReachedError while the
+// peer's limit for unidirectional streams is reached.
 func (s *Session) OpenUniStream() (*WritableStream, error) {
 	str, err := s.s.OpenUniStream()
 	if err != nil {
 		return nil, err
 	}
-	return &WritableStream{s: str}, nil
+	return s.wrapWritableStream(str, StreamOptions{}), nil
 }
 
-// AcceptStream accepts an incoming stream
+// OpenUniStreamOpts opens a new unidirectional stream tuned by opts. Like
+// OpenStreamOpts it blocks until the peer allows a new stream or ctx is
+// done. Only the send side options apply.
+func (s *Session) OpenUniStreamOpts(ctx context.Context, opts StreamOptions) (*WritableStream, error) {
+	str, err := s.s.OpenUniStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.wrapWritableStream(str, opts), nil
+}
+
+// AcceptStream accepts an incoming stream. It returns ErrAcceptTimeout
+// if a deadline was set with AcceptStreamDeadline and passed.
 func (s *Session) AcceptStream() (*Stream, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := s.acceptContext()
 	defer cancel()
-	
+
 	str, err := s.s.AcceptStream(ctx)
 	if err != nil {
-		if strings.HasPrefix(err.Error(), "Application error 0x0") {
+		if isClosedWithoutError(err) {
 			return nil, nil // Errorcode == 0 implies session is closed without error
 		}
-		return nil, err
+		return nil, acceptError(ctx, err)
 	}
-	return &Stream{s: str}, nil
+	return s.wrapStream(str, StreamOptions{}), nil
 }
 
-// AcceptUniStream accepts an incoming unidirectional stream and returns a ReadableStream
+// AcceptUniStream accepts an incoming unidirectional stream and returns a ReadableStream.
+// It returns ErrAcceptTimeout like AcceptStream.
 func (s *Session) AcceptUniStream() (*ReadableStream, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := s.acceptContext()
 	defer cancel()
-	
+
 	str, err := s.s.AcceptUniStream(ctx)
 	if err != nil {
-		if strings.HasPrefix(err.Error(), "Application error 0x0") {
+		if isClosedWithoutError(err) {
 			return nil, nil // Errorcode == 0 implies session is closed without error
 		}
-		return nil, err
+		return nil, acceptError(ctx, err)
 	}
-	return &ReadableStream{s: str}, nil
+	return s.wrapReadableStream(str), nil
+}
+
+// wrapReadableStream wraps a unidirectional stream opened by the peer.
+func (s *Session) wrapReadableStream(str *quic.ReceiveStream) *ReadableStream {
+	s.accepted.add(str.StreamID())
+	s.streamEvent(str.StreamID())
+	return &ReadableStream{s: str, totals: &s.totals, mem: s.t.mem}
+}
+
+// isClosedWithoutError reports whether err signals that the session was
+// closed with error code 0.
+func isClosedWithoutError(err error) bool {
+	return strings.HasPrefix(err.Error(), "Application error 0x0")
 }
 
 // GetRemoteCertificates returns the certificate chain presented by remote peer.
@@ -155,11 +544,46 @@ func (s *Session) GetRemoteCertificates() []*x509.Certificate {
 	return s.s.ConnectionState().TLS.PeerCertificates
 }
 
+// HadRetry reports whether the server forced address validation by sending
+// a Retry packet, costing the client an extra round trip during the
+// handshake. It is only meaningful on the client side of a session.
+func (s *Session) HadRetry() bool {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+	return s.t.retried
+}
+
+// PeerIdleTimeout returns the max_idle_timeout advertised by the peer in its
+// transport parameters, or zero if it did not advertise one. Keep-alives
+// only need to be sent somewhat more often than this.
+func (s *Session) PeerIdleTimeout() time.Duration {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+	if s.t.peerParams == nil {
+		return 0
+	}
+	return s.t.peerParams.MaxIdleTimeout
+}
+
 // Close the connection
 func (s *Session) Close() error {
 	return s.CloseWithError(0, io.EOF)
 }
 
+// Context returns a context that is canceled when the session ends,
+// whether it was closed locally, by the peer or by the idle timeout.
+// Contexts derived from it cancel the work tied to the session;
+// context.Cause tells why it ended, e.g. a *quic.ApplicationError for a
+// session closed by the application or a *quic.IdleTimeoutError.
+func (s *Session) Context() context.Context {
+	return s.s.Context()
+}
+
+// IsClosed reports whether the session ended, see Context.
+func (s *Session) IsClosed() bool {
+	return s.s.Context().Err() != nil
+}
+
 // CloseWithError closes the connection with an error.
 // The error must not be nil.
 func (s *Session) CloseWithError(code uint16, err error) error {
@@ -0,0 +1,133 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConfig returns a Config with a fresh self-signed certificate.
+func newTestConfig(t *testing.T) *Config {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return &Config{Certificate: cert, PrivateKey: priv, SkipVerify: true}
+}
+
+// newTestSessions connects a client to a server on the loopback interface.
+// Both are closed at the end of the test.
+func newTestSessions(t *testing.T, serverConfig, clientConfig *Config) (server, client *Session) {
+	t.Helper()
+
+	l, err := Listen("127.0.0.1:0", serverConfig)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	accepted := make(chan *Session, 1)
+	go func() {
+		s, err := l.Accept()
+		assert.NoError(t, err)
+		accepted <- s
+	}()
+
+	client, err = Dial(l.l.Addr().String(), clientConfig)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	server = <-accepted
+	if server == nil {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	return server, client
+}
+
+func TestSession_Context(t *testing.T) {
+	noKeepAlive := func(c *quic.Config) {
+		c.KeepAlivePeriod = 0
+		c.MaxIdleTimeout = 200 * time.Millisecond
+	}
+
+	testCases := []struct {
+		name string
+		// close ends the session observed by the server
+		close func(server, client *Session)
+		// cause is a pointer to the type of error expected as the cause
+		cause any
+	}{
+		{
+			name:  "Local",
+			close: func(server, _ *Session) { _ = server.CloseWithError(3, errors.New("local")) },
+			cause: new(*quic.ApplicationError),
+		},
+		{
+			name:  "Remote",
+			close: func(_, client *Session) { _ = client.CloseWithError(3, errors.New("remote")) },
+			cause: new(*quic.ApplicationError),
+		},
+		{
+			name:  "IdleTimeout",
+			close: func(*Session, *Session) {},
+			cause: new(*quic.IdleTimeoutError),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			serverConfig, clientConfig := newTestConfig(t), newTestConfig(t)
+			serverConfig.QUICConfigModifier = noKeepAlive
+			clientConfig.QUICConfigModifier = noKeepAlive
+			server, client := newTestSessions(t, serverConfig, clientConfig)
+
+			// work of a handler, tied to the session
+			ctx, cancel := context.WithCancel(server.Context())
+			defer cancel()
+			done := make(chan error, 1)
+			go func() {
+				<-ctx.Done()
+				done <- context.Cause(ctx)
+			}()
+			assert.False(t, server.IsClosed())
+
+			tc.close(server, client)
+			select {
+			case cause := <-done:
+				assert.ErrorAs(t, cause, tc.cause)
+			case <-time.After(5 * time.Second):
+				t.Fatal("context not canceled after the session closed")
+			}
+			assert.True(t, server.IsClosed())
+		})
+	}
+}
@@ -11,17 +11,19 @@ import (
 
 // ReadableStream represents a wrapped quic-go ReceiveStream
 type ReadableStream struct {
-	s *quic.ReceiveStream
+	s      *quic.ReceiveStream
+	totals *byteTotals // of the session, if any
+	mem    *memoryTracker
 }
 
 // Read implements the Conn Read method.
 func (s *ReadableStream) Read(p []byte) (int, error) {
-	return s.s.Read(p)
+	return s.read(p)
 }
 
 // ReadQuic reads a frame and determines if it is the final frame
 func (s *ReadableStream) ReadQuic(p []byte) (int, bool, error) {
-	n, err := s.s.Read(p)
+	n, err := s.read(p)
 	fin := false
 	if err != nil {
 		if errors.Is(err, io.EOF) {
@@ -38,6 +40,19 @@ func (s *ReadableStream) ReadQuic(p []byte) (int, bool, error) {
 	return n, fin, err
 }
 
+func (s *ReadableStream) read(p []byte) (int, error) {
+	n, err := s.s.Read(p)
+	s.totals.addReceived(n)
+	s.mem.read(s.s.StreamID(), n)
+	return n, err
+}
+
+// cancel stops reading the stream.
+func (s *ReadableStream) cancel(code quic.StreamErrorCode) {
+	s.s.CancelRead(code)
+	s.mem.discard(s.s.StreamID())
+}
+
 // StreamID returns the ID of the QuicStream
 func (s *ReadableStream) StreamID() uint64 {
 	return uint64(s.s.StreamID())
@@ -0,0 +1,182 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// Datagram types, sent as the first byte of every datagram if
+// Config.DatagramReceipts is set.
+const (
+	datagramPlain            = 0 // followed by the payload
+	datagramReceiptRequested = 1 // followed by a varint sequence number and the payload
+	datagramReceipt          = 2 // followed by the varint sequence number received
+)
+
+// receivedDatagramQueue is the number of received datagrams held when
+// receipts are enabled, matching quic-go's own queue.
+const receivedDatagramQueue = 128
+
+// Retransmission timeouts of SendDatagramWithReceipt.
+const (
+	minReceiptTimeout = 10 * time.Millisecond
+	maxReceiptTimeout = time.Second
+	initialRTT        = 100 * time.Millisecond // quic-go's RTT estimate before the first sample
+)
+
+var errReceiptsDisabled = errors.New("quic: datagram receipts not enabled")
+
+// datagramReceipts holds the state of a session with Config.DatagramReceipts.
+type datagramReceipts struct {
+	queue chan []byte // received payloads
+	acks  chan uint64 // sequence numbers of the receipts to send
+
+	lock    sync.Mutex
+	next    uint64
+	waiting map[uint64]chan struct{} // closed when the receipt arrived
+}
+
+func newDatagramReceipts() *datagramReceipts {
+	return &datagramReceipts{
+		queue:   make(chan []byte, receivedDatagramQueue),
+		acks:    make(chan uint64, receivedDatagramQueue),
+		waiting: make(map[uint64]chan struct{}),
+	}
+}
+
+// SendDatagramWithReceipt sends msg as a datagram and waits until the
+// peer acknowledged it with a receipt datagram, retransmitting it as
+// needed until ctx is done. Other datagrams stay unreliable. Both peers
+// must set Config.DatagramReceipts.
+//
+// A receipt is sent once the datagram was queued for the peer's
+// ReceiveDatagram or Subscribe, not when the application read it. A
+// datagram dropped because that queue is full is not acknowledged and
+// therefore retransmitted.
+//
+// The call takes at least one round trip. The first retransmission
+// happens after twice the smoothed RTT, each further one doubles the
+// wait up to one second. As receipts can be lost as well, the peer may
+// receive msg more than once. The sequence number adds up to 9 bytes to
+// the datagram, reducing the maximum payload accordingly. Retransmissions
+// and the peer's receipts count against the datagram rate limits of the
+// sending session; a blocking limit waits until ctx is done.
+func (s *Session) SendDatagramWithReceipt(ctx context.Context, msg []byte) error {
+	r := s.receipts
+	if r == nil {
+		return errReceiptsDisabled
+	}
+	acked := make(chan struct{})
+	r.lock.Lock()
+	seq := r.next
+	r.next++
+	r.waiting[seq] = acked
+	r.lock.Unlock()
+	defer func() {
+		r.lock.Lock()
+		delete(r.waiting, seq)
+		r.lock.Unlock()
+	}()
+
+	header := quicvarint.Append([]byte{datagramReceiptRequested}, seq)
+	timeout := s.receiptTimeout()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for first := true; ; first = false {
+		if err := s.sendFramedDatagram(ctx, header, msg); err != nil {
+			return err
+		}
+		if first {
+			s.totals.addSent(len(msg))
+		}
+
+		select {
+		case <-acked:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.s.Context().Done():
+			return context.Cause(s.s.Context())
+		case <-timer.C:
+			timeout = min(2*timeout, maxReceiptTimeout)
+			timer.Reset(timeout)
+		}
+	}
+}
+
+// receiptTimeout returns the time to wait for the first receipt.
+func (s *Session) receiptTimeout() time.Duration {
+	s.t.lock.Lock()
+	rtt := s.t.smoothedRTT
+	s.t.lock.Unlock()
+	if rtt == 0 {
+		rtt = initialRTT
+	}
+	return min(max(2*rtt, minReceiptTimeout), maxReceiptTimeout)
+}
+
+// readDatagrams receives the datagrams of a session with receipts,
+// queueing and processing the receipts, until the session ends.
+func (s *Session) readDatagrams() {
+	r := s.receipts
+	for {
+		p, err := s.s.ReceiveDatagram(s.s.Context())
+		if err != nil {
+			return
+		}
+		if len(p) == 0 {
+			continue
+		}
+		switch p[0] {
+		case datagramPlain:
+			select {
+			case r.queue <- p[1:]:
+			default:
+			}
+		case datagramReceiptRequested:
+			seq, n, err := quicvarint.Parse(p[1:])
+			if err != nil {
+				continue
+			}
+			select {
+			case r.queue <- p[1+n:]:
+				// Without room for the receipt, the peer retransmits.
+				select {
+				case r.acks <- seq:
+				default:
+				}
+			default:
+			}
+		case datagramReceipt:
+			seq, _, err := quicvarint.Parse(p[1:])
+			if err != nil {
+				continue
+			}
+			r.lock.Lock()
+			if acked, ok := r.waiting[seq]; ok {
+				close(acked)
+				delete(r.waiting, seq)
+			}
+			r.lock.Unlock()
+		}
+	}
+}
+
+// sendReceipts sends the receipts queued by readDatagrams until the session
+// ends. It runs on its own so that neither the rate limit nor a full
+// datagram queue holds up reading.
+func (s *Session) sendReceipts() {
+	ctx := s.s.Context()
+	for {
+		select {
+		case seq := <-s.receipts.acks:
+			_ = s.sendFramedDatagram(ctx, quicvarint.Append([]byte{datagramReceipt}, seq), nil)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
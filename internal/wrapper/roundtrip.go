@@ -0,0 +1,205 @@
+package wrapper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// errorCodeCanceled is the stream error code used when a request is
+// aborted because its context is done.
+const errorCodeCanceled quic.StreamErrorCode = 0
+
+// maxResponseHeaderSize bounds the header read by RoundTripStreaming.
+const maxResponseHeaderSize = 16 << 10
+
+var errResponseHeaderTooLarge = errors.New("quic: response header exceeds maximum size")
+
+// RoundTrip sends request on a new stream, finishes the write side and
+// returns the complete response written by the peer before it finished
+// the stream. Both directions of the stream are reset if ctx is done. On
+// the first stream of a client with Config.Preface, the preface is sent
+// before request.
+func (s *Session) RoundTrip(ctx context.Context, request []byte) ([]byte, error) {
+	str, stop, err := s.sendRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	response, err := io.ReadAll(str)
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return response, nil
+}
+
+// RoundTripOptions configure a request sent with RoundTripOpts or
+// Pool.RoundTrip.
+type RoundTripOptions struct {
+	// Idempotent marks the request as safe to be processed more than once
+	// by the peer. Only idempotent requests are retried.
+	Idempotent bool
+	// MaxRetries is the number of times an idempotent request is sent
+	// again after its stream was reset by the peer. Pool.RoundTrip also
+	// retries when the session ends during the request. Zero disables
+	// retries.
+	MaxRetries int
+}
+
+// RoundTripOpts works like RoundTrip, retrying the request on a new stream
+// as configured by opts. All attempts share ctx, so retries stop once its
+// deadline passed; the error of the last attempt is returned.
+//
+// A request that is not retried is sent at most once: if it fails, the
+// peer may or may not have processed it, but never processes it twice. A
+// retried request is sent at least once until a response is read, and the
+// peer may process it several times, e.g. if only the response was lost.
+func (s *Session) RoundTripOpts(ctx context.Context, request []byte, opts RoundTripOptions) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		response, err := s.RoundTrip(ctx, request)
+		if err == nil || !opts.retry(attempt) || !isStreamReset(err) {
+			return response, err
+		}
+	}
+}
+
+// retry reports whether a request failed attempt times already may be
+// sent again.
+func (o RoundTripOptions) retry(attempt int) bool {
+	return o.Idempotent && attempt < o.MaxRetries
+}
+
+// isStreamReset reports whether err was caused by the peer resetting the
+// stream.
+func isStreamReset(err error) bool {
+	var streamErr *quic.StreamError
+	return errors.As(err, &streamErr) && streamErr.Remote
+}
+
+// RoundTripStreaming works like RoundTrip but only reads the response up to
+// and including the first headerDelim byte. It returns the bytes before the
+// delimiter as header and the stream positioned at the start of the body,
+// so large bodies can be consumed incrementally.
+//
+// The peer is expected to frame its response as a header terminated by
+// headerDelim, followed by the body until the end of the stream. Headers
+// larger than 16 KiB are rejected. ctx governs the reading of the body as
+// well; the stream is reset if it is done before the body was consumed.
+// Once the body was read to the end or the stream was closed, the stream
+// is no longer bound to ctx and the request completes, see
+// SetMaxConcurrentRequests. Closing the stream before the end of the body
+// stops reading it with error code 0.
+func (s *Session) RoundTripStreaming(ctx context.Context, request []byte, headerDelim byte) ([]byte, *Stream, error) {
+	str, _, err := s.sendRequest(ctx, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	complete := str.onDone
+	str.onDone = func() {
+		complete()
+		str.s.CancelRead(errorCodeCanceled) // no-op once the body was read
+		str.mem.discard(str.s.StreamID())
+	}
+
+	str.r = bufio.NewReaderSize(str.s, maxResponseHeaderSize)
+	header, err := str.r.ReadSlice(headerDelim)
+	if err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			err = errResponseHeaderTooLarge
+		} else if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		str.cancel(errorCodeCanceled)
+		return nil, nil, contextError(ctx, err)
+	}
+	str.consumed(len(header))
+
+	return append([]byte(nil), header[:len(header)-1]...), str, nil
+}
+
+// sendRequest opens a stream bound to ctx and writes request to it.
+// Calling stop unbinds the stream from ctx, as does the end of its read
+// side, which also releases the request's slot.
+func (s *Session) sendRequest(ctx context.Context, request []byte) (str *Stream, stop func() bool, err error) {
+	release, err := s.acquireRequest(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	str, err = s.OpenStreamOpts(ctx, StreamOptions{})
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	stop = context.AfterFunc(ctx, func() {
+		str.cancel(errorCodeCanceled)
+	})
+	str.onDone = func() {
+		stop()
+		release()
+	}
+
+	if _, err = str.WriteQuic(request, true); err != nil {
+		stop()
+		str.cancel(errorCodeCanceled)
+		return nil, nil, contextError(ctx, err)
+	}
+	return str, stop, nil
+}
+
+// cancel resets both directions of the stream.
+func (s *Stream) cancel(code quic.StreamErrorCode) {
+	s.s.CancelRead(code)
+	s.s.CancelWrite(code)
+	s.mem.discard(s.s.StreamID())
+	s.reset.Store(true)
+	s.done()
+}
+
+// contextError prefers the context's error over the stream error it caused.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// SetMaxConcurrentRequests limits the number of RoundTrip and
+// RoundTripStreaming calls in flight on the session. Excess calls block
+// until a request completes or their context is done. A request completes
+// once its response was read to the end, or its stream was closed or
+// reset.
+// A value of n <= 0 removes the limit. Requests already in flight keep
+// counting against the limit they were started with.
+func (s *Session) SetMaxConcurrentRequests(n int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if n <= 0 {
+		s.requests = nil
+		return
+	}
+	s.requests = make(chan struct{}, n)
+}
+
+func (s *Session) acquireRequest(ctx context.Context) (release func(), err error) {
+	s.lock.Lock()
+	sem := s.requests
+	s.lock.Unlock()
+
+	if sem == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
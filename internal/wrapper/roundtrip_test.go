@@ -0,0 +1,83 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_MaxConcurrentRequests_Close(t *testing.T) {
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+	go func() {
+		_ = server.ServeStreams(context.Background(), func(str *Stream) {
+			if _, err := io.ReadAll(str); err != nil {
+				return
+			}
+			_, _ = str.WriteQuic([]byte("header\n"), false)
+			_, _ = str.WriteQuic(make([]byte, 1<<20), true)
+		}, 4)
+	}()
+	client.SetMaxConcurrentRequests(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		header, str, err := client.RoundTripStreaming(ctx, []byte("request"), '\n')
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "header", string(header))
+		// Abandoning the body gives the request's slot back.
+		assert.NoError(t, str.Close())
+	}
+}
+
+func TestRoundTripStreaming_MemoryUsage(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		readBody bool
+	}{
+		{"ReadBody", true},
+		{"CloseEarly", false},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			clientCfg := newTestConfig(t)
+			clientCfg.MaxSessionMemory = 4 << 20
+			server, client := newTestSessions(t, newTestConfig(t), clientCfg)
+			go func() {
+				_ = server.ServeStreams(context.Background(), func(str *Stream) {
+					if _, err := io.ReadAll(str); err != nil {
+						return
+					}
+					_, _ = str.WriteQuic([]byte("header\n"), false)
+					_, _ = str.WriteQuic(make([]byte, 1<<20), true)
+				}, 1)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			header, str, err := client.RoundTripStreaming(ctx, []byte("request"), '\n')
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, "header", string(header))
+			if tc.readBody {
+				body, err := io.ReadAll(str)
+				assert.NoError(t, err)
+				assert.Len(t, body, 1<<20)
+			} else {
+				assert.NoError(t, str.Close())
+			}
+			assert.Eventually(t, func() bool {
+				return client.MemoryUsage() == 0
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package wrapper
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// scannerReadSize is the size of the read buffer of a StreamScanner.
+const scannerReadSize = 4 << 10 // 4 kB
+
+// StreamScanner splits the data read from a stream into tokens ended by a
+// delimiter, like a bufio.Scanner. Unlike a bufio.Scanner, it does not
+// consume data beyond the last token returned, so the stream can be read
+// directly through Reader once scanning stops, e.g. to switch from a text
+// header to a binary body.
+type StreamScanner struct {
+	s       *Stream
+	r       *bufio.Reader
+	delim   byte
+	maxSize int
+
+	token []byte
+	err   error
+}
+
+// Scanner returns a StreamScanner for tokens ended by delim, such as '\n'
+// for newline-delimited protocols. Tokens are limited to
+// bufio.MaxScanTokenSize bytes unless changed with SetMaxTokenSize.
+func (s *Stream) Scanner(delim byte) *StreamScanner {
+	return &StreamScanner{
+		s:       s,
+		r:       bufio.NewReaderSize(s, scannerReadSize),
+		delim:   delim,
+		maxSize: bufio.MaxScanTokenSize,
+	}
+}
+
+// SetMaxTokenSize limits the size of a token, excluding the delimiter, to
+// n bytes. It must be called before Scan.
+func (sc *StreamScanner) SetMaxTokenSize(n int) {
+	sc.maxSize = n
+}
+
+// Scan advances to the next token, which is then available through Bytes
+// and Text. It returns false at the end of the stream or on an error,
+// reported by Err. The last token may lack the delimiter if the stream
+// ends without one.
+//
+// A token exceeding the maximum size stops scanning with bufio.ErrTooLong
+// without reading the rest of it; the stream is then reset, as its data
+// can no longer be split reliably.
+func (sc *StreamScanner) Scan() bool {
+	if sc.err != nil {
+		return false
+	}
+	sc.token = sc.token[:0]
+	for {
+		chunk, err := sc.r.ReadSlice(sc.delim)
+		if err == nil {
+			chunk = chunk[:len(chunk)-1] // strip the delimiter
+		}
+		if len(sc.token)+len(chunk) > sc.maxSize {
+			sc.s.cancel(errorCodeCanceled)
+			sc.err = bufio.ErrTooLong
+			sc.token = nil
+			return false
+		}
+		sc.token = append(sc.token, chunk...)
+
+		switch {
+		case err == nil:
+			return true
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF) && len(sc.token) > 0:
+			return true // the final token, the error is reported next time
+		default:
+			sc.err = err
+			return false
+		}
+	}
+}
+
+// Bytes returns the token found by the last Scan, without the delimiter.
+// It may be overwritten by the next call to Scan.
+func (sc *StreamScanner) Bytes() []byte {
+	return sc.token
+}
+
+// Text returns the token found by the last Scan as a string.
+func (sc *StreamScanner) Text() string {
+	return string(sc.token)
+}
+
+// Err returns the error that stopped scanning. It is nil if the stream
+// ended normally.
+func (sc *StreamScanner) Err() error {
+	if errors.Is(sc.err, io.EOF) {
+		return nil
+	}
+	return sc.err
+}
+
+// Reader returns a reader continuing right after the last token scanned,
+// including the data the scanner read ahead.
+func (sc *StreamScanner) Reader() io.Reader {
+	return sc.r
+}
@@ -0,0 +1,229 @@
+package wrapper
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Priority classes of StreamOptions.Priority. Values above PriorityHigh
+// and below PriorityLow are treated like these.
+//
+// quic-go has no stream priorities: it sends the streams with pending data
+// round-robin, one packet's worth each. This is fair between the streams of
+// a class, so a stream with a large backlog does not starve the others and
+// no further scheduling is needed within a class: every stream that quic-go
+// holds data for gets its share of each flow and congestion control window
+// in turn. Priorities are therefore enforced in front of quic-go: writes
+// are handed to quic-go in chunks of 16 kB, and a chunk waits while writes
+// of a higher class are in progress on the session. A higher class that
+// handed no data to quic-go for 25 ms, e.g. because it is blocked by flow
+// control, no longer holds the others back until it makes progress again. A
+// waiting chunk still fails once the stream's write deadline passes or the
+// stream or session ends. Once handed to quic-go, a chunk shares the
+// connection with the streams of all classes, so the ordering is coarse and
+// only applies to data written concurrently. Priorities are local; they are
+// not sent to the peer and do not affect its sending.
+//
+// Scheduling starts once a stream with a priority other than
+// PriorityNormal is opened on the session; until then, writes are handed
+// to quic-go directly.
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+const (
+	// schedulerChunkSize is the amount of data handed to quic-go at once
+	// by a scheduled write, bounding how long a lower priority write holds
+	// up a higher priority one.
+	schedulerChunkSize = 16 << 10 // 16 kB
+	// maxPriorityWait is how long a class may hand no data to quic-go
+	// while writing before lower classes stop waiting for it, so a higher
+	// priority stream blocked by flow control does not stall the others.
+	maxPriorityWait = 25 * time.Millisecond
+)
+
+// class returns the index of p in scheduler.active.
+func (p Priority) class() int {
+	switch {
+	case p > PriorityNormal:
+		return 2
+	case p < PriorityNormal:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// scheduler orders the writes of a session's streams by priority class.
+type scheduler struct {
+	enabled atomic.Bool // a stream with a priority other than PriorityNormal was opened
+
+	lock     sync.Mutex
+	active   [3]int        // writes in progress per class
+	progress [3]time.Time  // when the writes of a class last handed data to quic-go
+	changed  chan struct{} // closed and replaced when a class became idle
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{changed: make(chan struct{})}
+}
+
+// start marks a write of class c in progress.
+func (s *scheduler) start(c int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.active[c]++
+	if s.active[c] == 1 {
+		s.progress[c] = time.Now()
+	}
+}
+
+// finish marks a write of class c as done.
+func (s *scheduler) finish(c int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.active[c]--
+	if s.active[c] == 0 {
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+}
+
+// progressed records that a write of class c handed data to quic-go.
+func (s *scheduler) progressed(c int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.progress[c] = time.Now()
+}
+
+// wait blocks while writes of a class higher than c are in progress and
+// made progress within maxPriorityWait. It stops waiting once ctx is done
+// or deadline, which it loads on every wakeup, has passed.
+func (s *scheduler) wait(ctx context.Context, c int, deadline *atomic.Int64) error {
+	for {
+		s.lock.Lock()
+		now := time.Now()
+		d := s.holdback(c, now)
+		changed := s.changed
+		s.lock.Unlock()
+		if d <= 0 {
+			return nil
+		}
+		if dl := deadline.Load(); dl != 0 {
+			left := time.Unix(0, dl).Sub(now)
+			if left <= 0 {
+				return os.ErrDeadlineExceeded
+			}
+			d = min(d, left)
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-ctx.Done():
+			// The write fails with the stream's or the session's error.
+			timer.Stop()
+			return nil
+		}
+		timer.Stop()
+	}
+}
+
+// holdback returns how long the writes of class c still wait for the
+// higher classes in progress. The lock must be held.
+func (s *scheduler) holdback(c int, now time.Time) time.Duration {
+	var d time.Duration
+	for h := c + 1; h < len(s.active); h++ {
+		if s.active[h] > 0 {
+			d = max(d, s.progress[h].Add(maxPriorityWait).Sub(now))
+		}
+	}
+	return d
+}
+
+// scheduledWriter hands the data written to a stream to quic-go in chunks
+// ordered by the scheduler, once it is enabled.
+type scheduledWriter struct {
+	w     io.Writer
+	ctx   context.Context // done once the stream can no longer be written
+	sched *scheduler
+	class int
+
+	deadline atomic.Int64 // write deadline in Unix nanoseconds, zero if none
+}
+
+func (w *scheduledWriter) Write(p []byte) (int, error) {
+	if !w.sched.enabled.Load() {
+		return w.w.Write(p)
+	}
+	w.sched.start(w.class)
+	defer w.sched.finish(w.class)
+
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), schedulerChunkSize)]
+		if err := w.sched.wait(w.ctx, w.class, &w.deadline); err != nil {
+			return written, err
+		}
+		n, err := w.w.Write(chunk)
+		w.sched.progressed(w.class)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// setDeadline records the write deadline of the stream, which quic-go
+// does not expose, for the writes waiting for higher classes.
+func (w *scheduledWriter) setDeadline(t time.Time) {
+	if t.IsZero() {
+		w.deadline.Store(0)
+	} else {
+		w.deadline.Store(t.UnixNano())
+	}
+}
+
+// setWriteDeadline forwards the write deadline of a stream writing to dst
+// to its scheduler, if any.
+func setWriteDeadline(dst io.Writer, t time.Time) {
+	if w, ok := dst.(*scheduledWriter); ok {
+		w.setDeadline(t)
+	}
+}
+
+// newScheduledWriter returns the writer scheduling the writes of a stream
+// of priority p to w, enabling sched unless p is PriorityNormal. ctx is
+// the context of the stream's write side.
+func newScheduledWriter(ctx context.Context, w io.Writer, sched *scheduler, p Priority) *scheduledWriter {
+	c := p.class()
+	if c != PriorityNormal.class() {
+		sched.enabled.Store(true)
+	}
+	return &scheduledWriter{w: w, ctx: ctx, sched: sched, class: c}
+}
+
+// useScheduler makes the stream's writes go through sched.
+func (s *Stream) useScheduler(sched *scheduler) {
+	s.dst = newScheduledWriter(s.s.Context(), s.s, sched, s.opts.Priority)
+	if s.w != nil {
+		s.w.Reset(s.dst)
+	}
+}
+
+// useScheduler makes the stream's writes go through sched.
+func (s *WritableStream) useScheduler(sched *scheduler, p Priority) {
+	s.dst = newScheduledWriter(s.s.Context(), s.s, sched, p)
+	if s.w != nil {
+		s.w.Reset(s.dst)
+	}
+}
@@ -0,0 +1,222 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScheduler_Interleaving shows that a small response is not held up by
+// a large one written concurrently on the same session.
+func TestScheduler_Interleaving(t *testing.T) {
+	const largeSize, smallSize, started = 16 << 20, 16 << 10, 1 << 20
+
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+	largeStarted := make(chan struct{})
+	results := make(chan int64, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			str, err := server.AcceptStream()
+			if err != nil || str == nil {
+				return
+			}
+			large := i == 0 // the small stream is opened later
+			go func() {
+				var n int64
+				if large {
+					m, err := io.CopyN(io.Discard, str, started)
+					if err != nil {
+						return
+					}
+					n = m
+					close(largeStarted)
+				}
+				m, _ := io.Copy(io.Discard, str)
+				results <- n + m
+			}()
+		}
+	}()
+
+	large, err := client.OpenStream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	largeWritten := make(chan error, 1)
+	go func() {
+		_, err := large.WriteQuic(make([]byte, largeSize), true)
+		largeWritten <- err
+	}()
+	select {
+	case <-largeStarted: // the large stream has a backlog
+	case <-time.After(5 * time.Second):
+		t.Fatal("large stream did not start")
+	}
+
+	small, err := client.OpenStream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = small.WriteQuic(make([]byte, smallSize), true)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(smallSize), <-results, "the small stream completes first")
+	assert.Equal(t, int64(largeSize), <-results)
+	assert.NoError(t, <-largeWritten)
+}
+
+// TestScheduler_Priority shows that a high priority stream is sent before a
+// low priority one writing concurrently, although the latter started first.
+func TestScheduler_Priority(t *testing.T) {
+	const lowSize, highSize, started = 8 << 20, 16 << 20, 1 << 20
+
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+	lowStarted := make(chan struct{})
+	completed := make(chan Priority, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			str, err := server.AcceptStream()
+			if err != nil || str == nil {
+				return
+			}
+			go func() {
+				var p [1]byte
+				if _, err := io.ReadFull(str, p[:]); err != nil {
+					return
+				}
+				priority := Priority(int8(p[0]))
+				if priority == PriorityLow {
+					if _, err := io.CopyN(io.Discard, str, started); err != nil {
+						return
+					}
+					close(lowStarted)
+				}
+				if _, err := io.Copy(io.Discard, str); err == nil {
+					completed <- priority
+				}
+			}()
+		}
+	}()
+
+	write := func(p Priority, size int) <-chan error {
+		written := make(chan error, 1)
+		str, err := client.OpenStreamOpts(context.Background(), StreamOptions{Priority: p})
+		if err != nil {
+			written <- err
+			return written
+		}
+		go func() {
+			data := make([]byte, size)
+			data[0] = byte(int8(p))
+			_, err := str.WriteQuic(data, true)
+			written <- err
+		}()
+		return written
+	}
+
+	lowWritten := write(PriorityLow, lowSize)
+	select {
+	case <-lowStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("low priority stream did not start")
+	}
+	highWritten := write(PriorityHigh, highSize)
+
+	assert.Equal(t, PriorityHigh, <-completed, "the high priority stream completes first")
+	assert.Equal(t, PriorityLow, <-completed)
+	assert.NoError(t, <-highWritten)
+	assert.NoError(t, <-lowWritten)
+}
+
+// TestScheduler_WaitEnds shows that a low priority write held back by a
+// saturating high priority stream still ends with its deadline or reset.
+func TestScheduler_WaitEnds(t *testing.T) {
+	const started = 1 << 20
+
+	testCases := []struct {
+		name string
+		// end makes the waiting write to str fail.
+		end func(str *Stream)
+		err error
+	}{
+		{
+			name: "Deadline",
+			end: func(str *Stream) {
+				_ = str.SetDeadline(time.Now().Add(100 * time.Millisecond))
+			},
+			err: os.ErrDeadlineExceeded,
+		},
+		{
+			name: "Reset",
+			end: func(str *Stream) {
+				time.AfterFunc(100*time.Millisecond, func() { str.cancel(errorCodeCanceled) })
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+			highStarted := make(chan struct{})
+			go func() {
+				str, err := server.AcceptStream()
+				if err != nil || str == nil {
+					return
+				}
+				if _, err := io.CopyN(io.Discard, str, started); err != nil {
+					return
+				}
+				close(highStarted)
+				_, _ = io.Copy(io.Discard, str)
+			}()
+
+			high, err := client.OpenStreamOpts(context.Background(), StreamOptions{Priority: PriorityHigh})
+			if !assert.NoError(t, err) {
+				return
+			}
+			go func() {
+				// saturate the session until it is closed
+				data := make([]byte, 1<<20)
+				for {
+					if _, err := high.WriteQuic(data, false); err != nil {
+						return
+					}
+				}
+			}()
+			select {
+			case <-highStarted:
+			case <-time.After(5 * time.Second):
+				t.Fatal("high priority stream did not start")
+			}
+
+			low, err := client.OpenStreamOpts(context.Background(), StreamOptions{Priority: PriorityLow})
+			if !assert.NoError(t, err) {
+				return
+			}
+			tc.end(low)
+			lowWritten := make(chan error, 1)
+			go func() {
+				_, err := low.WriteQuic(make([]byte, 1<<20), true)
+				lowWritten <- err
+			}()
+			select {
+			case err := <-lowWritten:
+				assert.Error(t, err)
+				if tc.err != nil {
+					assert.ErrorIs(t, err, tc.err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("low priority write did not end")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package wrapper
+
+import (
+	"github.com/quic-go/quic-go/logging"
+)
+
+// receivedMaxStreamData records the peer raising the flow control limit of
+// stream id.
+func (t *tracer) receivedMaxStreamData(id logging.StreamID, limit logging.ByteCount) {
+	t.lock.Lock()
+	p := t.sending[id]
+	t.lock.Unlock()
+	if p == nil {
+		return
+	}
+	for {
+		old := p.maxStreamData.Load()
+		if int64(limit) <= old || p.maxStreamData.CompareAndSwap(old, int64(limit)) {
+			break
+		}
+	}
+	if f := p.onCredit.Load(); f != nil {
+		(*f)()
+	}
+}
+
+// receivedMaxData records the peer raising the connection flow control
+// limit.
+func (t *tracer) receivedMaxData(limit logging.ByteCount) {
+	t.lock.Lock()
+	t.maxData = max(t.maxData, limit)
+	t.lock.Unlock()
+	t.creditChanged()
+}
+
+// sentDataBlocked records that we announced being blocked by the
+// connection flow control limit.
+func (t *tracer) sentDataBlocked(limit logging.ByteCount) {
+	t.lock.Lock()
+	t.dataBlocked = limit
+	t.lock.Unlock()
+	t.creditChanged()
+}
+
+// creditChanged notifies the streams waiting for flow control credit.
+func (t *tracer) creditChanged() {
+	var callbacks []func()
+	t.lock.Lock()
+	for _, p := range t.sending {
+		if f := p.onCredit.Load(); f != nil {
+			callbacks = append(callbacks, *f)
+		}
+	}
+	t.lock.Unlock()
+	for _, f := range callbacks {
+		f()
+	}
+}
+
+// uniStreamCredit returns the peer's flow control limit of the local
+// unidirectional stream tracked by p, and whether the connection is
+// blocked by the peer's connection flow control limit.
+func (t *tracer) uniStreamCredit(p *sendProgress) (limit logging.ByteCount, connBlocked bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	limit = logging.ByteCount(p.maxStreamData.Load())
+	maxData := t.maxData
+	if t.peerParams != nil {
+		limit = max(limit, t.peerParams.InitialMaxStreamDataUni)
+		maxData = max(maxData, t.peerParams.InitialMaxData)
+	}
+	return limit, t.dataBlocked > 0 && maxData <= t.dataBlocked
+}
@@ -0,0 +1,100 @@
+package wrapper
+
+import (
+	"context"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// errorCodeInternal is the stream error code used when a stream handler
+// panicked.
+const errorCodeInternal quic.StreamErrorCode = 1
+
+// ServeStreams accepts incoming streams and dispatches each one to handler,
+// running at most workers handlers at once. While all workers are busy no
+// further streams are accepted, leaving the peer blocked on its stream
+// limit. A panicking handler has its stream reset with error code 1; the
+// other streams are not affected.
+//
+// ServeStreams returns after all handlers finished, once the session was
+// closed or ctx is done. It returns nil if the session was closed without
+// error and the context's error if ctx is done.
+func (s *Session) ServeStreams(ctx context.Context, handler func(*Stream), workers int) error {
+	return serve(ctx, workers, func(ctx context.Context) (func(), error) {
+		str, err := s.s.AcceptStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stream := s.wrapStream(str, StreamOptions{})
+		return func() {
+			defer func() {
+				if recover() != nil {
+					stream.cancel(errorCodeInternal)
+				}
+			}()
+			handler(stream)
+		}, nil
+	})
+}
+
+// ServeUniStreams is like ServeStreams for unidirectional streams, which
+// are accepted independently, so both can be served at once with separate
+// worker limits. A panicking handler has its stream canceled with error
+// code 1.
+func (s *Session) ServeUniStreams(ctx context.Context, handler func(*ReadableStream), workers int) error {
+	return serve(ctx, workers, func(ctx context.Context) (func(), error) {
+		str, err := s.s.AcceptUniStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stream := s.wrapReadableStream(str)
+		return func() {
+			defer func() {
+				if recover() != nil {
+					stream.cancel(errorCodeInternal)
+				}
+			}()
+			handler(stream)
+		}, nil
+	})
+}
+
+// serve runs the jobs returned by accept on a pool of workers until accept
+// fails.
+func serve(ctx context.Context, workers int, accept func(context.Context) (func(), error)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	slots := make(chan struct{}, workers)
+	defer func() {
+		// wait for the running jobs
+		for i := 0; i < workers; i++ {
+			slots <- struct{}{}
+		}
+	}()
+
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		job, err := accept(ctx)
+		if err != nil {
+			<-slots
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if isClosedWithoutError(err) {
+				return nil
+			}
+			return err
+		}
+
+		go func() {
+			defer func() { <-slots }()
+			job()
+		}()
+	}
+}
@@ -0,0 +1,39 @@
+package wrapper
+
+import "net"
+
+// recommendedReceiveBufferSize is the socket receive buffer size quic-go
+// tries to set.
+const recommendedReceiveBufferSize = 7 << 20 // 7 MB
+
+// checkReceiveBuffer raises the receive buffer of pc to the recommended
+// size the way quic-go does, falling back to SO_RCVBUFFORCE on Linux,
+// which succeeds with CAP_NET_ADMIN, and reports to
+// config.OnSocketBufferWarning if that failed. quic-go then finds the
+// buffer set and keeps it. Otherwise quic-go still logs its own warning
+// once per process unless QUIC_GO_DISABLE_RECEIVE_BUFFER_WARNING=true is
+// set in the environment.
+func checkReceiveBuffer(pc net.PacketConn, config *Config) {
+	if config.OnSocketBufferWarning == nil {
+		return
+	}
+	conn := udpConn(pc)
+	if conn == nil {
+		return
+	}
+	size, err := readBufferSize(conn)
+	if err != nil || size >= recommendedReceiveBufferSize {
+		return
+	}
+	_ = conn.SetReadBuffer(recommendedReceiveBufferSize)
+	if size, err = readBufferSize(conn); err == nil && size < recommendedReceiveBufferSize {
+		_ = forceReadBuffer(conn, recommendedReceiveBufferSize)
+		size, err = readBufferSize(conn)
+	}
+	if err != nil {
+		return
+	}
+	if size < recommendedReceiveBufferSize {
+		config.OnSocketBufferWarning(size, recommendedReceiveBufferSize)
+	}
+}
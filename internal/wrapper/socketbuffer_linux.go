@@ -0,0 +1,25 @@
+//go:build linux
+
+package wrapper
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// forceReadBuffer sets the receive buffer of conn with SO_RCVBUFFORCE,
+// which exceeds net.core.rmem_max if the process has CAP_NET_ADMIN.
+func forceReadBuffer(conn *net.UDPConn, size int) error {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	if err := rawConn.Control(func(fd uintptr) {
+		serr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_RCVBUFFORCE, size)
+	}); err != nil {
+		return err
+	}
+	return serr
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package wrapper
+
+import "net"
+
+// forceReadBuffer is only supported on Linux.
+func forceReadBuffer(*net.UDPConn, int) error {
+	return nil
+}
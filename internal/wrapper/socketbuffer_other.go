@@ -0,0 +1,12 @@
+//go:build !unix
+
+package wrapper
+
+import (
+	"errors"
+	"net"
+)
+
+func readBufferSize(*net.UDPConn) (int, error) {
+	return 0, errors.New("quic: reading the socket buffer size is not supported")
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package wrapper
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// readBufferSize returns the receive buffer size reported by the kernel.
+// Linux reports twice the size that was set, as quic-go expects.
+func readBufferSize(conn *net.UDPConn) (int, error) {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var size int
+	var serr error
+	if err := rawConn.Control(func(fd uintptr) {
+		size, serr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_RCVBUF)
+	}); err != nil {
+		return 0, err
+	}
+	return size, serr
+}
@@ -0,0 +1,102 @@
+package wrapper
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/logging"
+)
+
+// sendProgress tracks whether a stream waits for quic-go to send its data.
+type sendProgress struct {
+	writers atomic.Int32 // writes and flushes in progress
+	since   atomic.Int64 // unix nanoseconds the stream last made progress
+
+	maxStreamData atomic.Int64           // largest MAX_STREAM_DATA received, see Writable
+	onCredit      atomic.Pointer[func()] // called when the flow control credit changed
+}
+
+// begin marks the start of a write or flush.
+func (p *sendProgress) begin() {
+	if p == nil {
+		return
+	}
+	if p.writers.Add(1) == 1 {
+		p.since.Store(time.Now().UnixNano())
+	}
+}
+
+// end marks the end of a write or flush.
+func (p *sendProgress) end() {
+	if p != nil {
+		p.writers.Add(-1)
+	}
+}
+
+// trackSending registers stream id with StarvedStreams until ctx, the
+// context of its send side, is done.
+func (t *tracer) trackSending(ctx context.Context, id quic.StreamID) *sendProgress {
+	p := &sendProgress{}
+	t.lock.Lock()
+	if t.sending == nil {
+		t.sending = make(map[logging.StreamID]*sendProgress)
+	}
+	t.sending[id] = p
+	t.lock.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		if t.sending[id] == p {
+			delete(t.sending, id)
+		}
+	})
+	return p
+}
+
+// sentStreamFrames records the progress of the streams sending in a packet.
+func (t *tracer) sentStreamFrames(frames []logging.Frame) {
+	var now int64
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, f := range frames {
+		sf, ok := f.(*logging.StreamFrame)
+		if !ok {
+			continue
+		}
+		if p, ok := t.sending[sf.StreamID]; ok {
+			if now == 0 {
+				now = time.Now().UnixNano()
+			}
+			p.since.Store(now)
+		}
+	}
+}
+
+// StarvedStreams returns the IDs of the streams, in ascending order, that
+// wait for data to be sent and did not get any STREAM frame sent for at
+// least threshold. It helps to diagnose unfair scheduling of streams
+// competing for the connection's flow and congestion control windows.
+//
+// A stream is waiting while a Write or Flush of it is blocked in quic-go;
+// data held in a send buffer without a flush does not count. Progress is
+// taken from the packets reported by quic-go's tracer, retransmissions
+// included.
+func (s *Session) StarvedStreams(threshold time.Duration) []uint64 {
+	cutoff := time.Now().Add(-threshold).UnixNano()
+
+	s.t.lock.Lock()
+	var starved []uint64
+	for id, p := range s.t.sending {
+		if p.writers.Load() > 0 && p.since.Load() <= cutoff {
+			starved = append(starved, uint64(id))
+		}
+	}
+	s.t.lock.Unlock()
+
+	sort.Slice(starved, func(i, j int) bool { return starved[i] < starved[j] })
+	return starved
+}
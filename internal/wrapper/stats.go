@@ -0,0 +1,85 @@
+package wrapper
+
+import "time"
+
+// SendLimit tells what currently limits sending on a session.
+type SendLimit int
+
+const (
+	// SendLimitNone means sending is limited by the application only.
+	SendLimitNone SendLimit = iota
+	// SendLimitFlowControl means the peer's flow control window is
+	// exhausted. Raising the peer's receive windows helps.
+	SendLimitFlowControl
+	// SendLimitCongestion means the congestion window is full, i.e. the
+	// network path is the bottleneck.
+	SendLimitCongestion
+)
+
+func (l SendLimit) String() string {
+	switch l {
+	case SendLimitNone:
+		return "none"
+	case SendLimitFlowControl:
+		return "flow-control"
+	case SendLimitCongestion:
+		return "congestion"
+	default:
+		return "unknown"
+	}
+}
+
+// SessionStats holds statistics of a session gathered from quic-go's tracer.
+type SessionStats struct {
+	// SendLimit is what limited sending most recently. Flow control
+	// blocking is detected from the DATA_BLOCKED and STREAM_DATA_BLOCKED
+	// frames sent and ends with the next MAX_DATA or MAX_STREAM_DATA
+	// frame received, so it is coarse: it does not tell which stream was
+	// blocked.
+	SendLimit SendLimit
+
+	// CongestionWindow is the current congestion window in bytes, or
+	// zero before quic-go reported its first metrics update.
+	CongestionWindow uint64
+	// PacingRate is the rate in bytes per second at which quic-go paces
+	// its packets, or zero until an RTT sample is available. quic-go does
+	// not expose it, so it is derived the way its pacer computes it: the
+	// congestion window per smoothed RTT, raised by a quarter.
+	PacingRate uint64
+	// BytesInFlight is the size in bytes of the packets sent but neither
+	// acknowledged nor declared lost, including retransmissions and
+	// framing. It covers all streams and datagrams of the session.
+	BytesInFlight uint64
+}
+
+// Stats returns the session's statistics.
+func (s *Session) Stats() SessionStats {
+	s.t.lock.Lock()
+	defer s.t.lock.Unlock()
+
+	var stats SessionStats
+	switch {
+	case s.t.flowBlocked:
+		stats.SendLimit = SendLimitFlowControl
+	case s.t.congestionWindow > 0 && s.t.bytesInFlight >= s.t.congestionWindow:
+		stats.SendLimit = SendLimitCongestion
+	}
+	stats.CongestionWindow = uint64(s.t.congestionWindow)
+	stats.BytesInFlight = uint64(s.t.bytesInFlight)
+	if s.t.smoothedRTT > 0 {
+		bandwidth := uint64(s.t.congestionWindow) * uint64(time.Second) / uint64(s.t.smoothedRTT)
+		stats.PacingRate = bandwidth * 5 / 4
+	}
+	return stats
+}
+
+// CloseWithStats closes the session like CloseWithError and returns its
+// statistics taken right before. A non-zero BytesInFlight tells that the
+// peer may not have received all data sent, e.g. when debugging truncated
+// transfers. Data still held in send buffers of the wrapper or quic-go is
+// not included; flush the streams and use WaitDrained to make sure it is
+// delivered.
+func (s *Session) CloseWithStats(code uint16, err error) (SessionStats, error) {
+	stats := s.Stats()
+	return stats, s.CloseWithError(code, err)
+}
@@ -1,9 +1,13 @@
 package wrapper
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"io"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	quic "github.com/quic-go/quic-go"
@@ -11,17 +15,49 @@ import (
 
 // Stream represents a wrapped quic-go Stream
 type Stream struct {
-	s *quic.Stream
+	s    *quic.Stream
+	dst  io.Writer // s, or a writer scheduling the writes to it
+	r    *bufio.Reader
+	w    *bufio.Writer
+	opts StreamOptions
+
+	wlock sync.Mutex // guards w, which writes and Flush may use concurrently
+
+	doneOnce sync.Once
+	onDone   func() // called once the read side ended or the stream was closed
+	ended    atomic.Bool
+
+	lastActive atomic.Int64 // unix nanoseconds of the last read or write
+
+	bytesRead    atomic.Uint64
+	bytesWritten atomic.Uint64
+	reset        atomic.Bool
+	totals       *byteTotals // of the session, if any
+	mem          *memoryTracker
+	progress     *sendProgress // nil unless opened through a Session
+	client       bool          // the stream belongs to the client side of a session
+}
+
+func newStream(str *quic.Stream, opts StreamOptions) *Stream {
+	s := &Stream{s: str, dst: str, opts: opts}
+	s.touch()
+	if opts.SendBufferSize > 0 {
+		s.w = bufio.NewWriterSize(str, clampStreamBufferSize(opts.SendBufferSize))
+	}
+	if opts.ReceiveBufferSize > 0 {
+		s.r = bufio.NewReaderSize(str, clampStreamBufferSize(opts.ReceiveBufferSize))
+	}
+	return s
 }
 
 // Read implements the Conn Read method.
 func (s *Stream) Read(p []byte) (int, error) {
-	return s.s.Read(p)
+	return s.read(p)
 }
 
 // ReadQuic reads a frame and determines if it is the final frame
 func (s *Stream) ReadQuic(p []byte) (int, bool, error) {
-	n, err := s.s.Read(p)
+	n, err := s.read(p)
 	fin := false
 	if err != nil {
 		if errors.Is(err, io.EOF) {
@@ -40,34 +76,204 @@ func (s *Stream) ReadQuic(p []byte) (int, bool, error) {
 
 // Write implements the Conn Write method.
 func (s *Stream) Write(p []byte, fin bool) (int, error) {
-	return s.s.Write(p)
+	return s.write(p)
 }
 
-// WriteQuic writes a frame and closes the stream if fin is true
+// WriteQuic writes a frame and closes the stream if fin is true. An empty
+// p with fin only finishes the stream; the peer then reads zero bytes and
+// io.EOF.
 func (s *Stream) WriteQuic(p []byte, fin bool) (int, error) {
-	n, err := s.s.Write(p)
+	n, err := s.write(p)
 	if err != nil {
 		return n, err
 	}
 	if fin {
-		return n, s.s.Close()
+		return n, s.CloseWrite()
 	}
 	return n, nil
 }
 
+func (s *Stream) read(p []byte) (n int, err error) {
+	if s.r != nil {
+		n, err = s.r.Read(p)
+	} else {
+		n, err = s.s.Read(p)
+	}
+	s.consumed(n)
+	if err != nil && !isTimeout(err) {
+		s.observeError(err)
+		s.done()
+	}
+	return n, err
+}
+
+// consumed accounts for n bytes returned to the application.
+func (s *Stream) consumed(n int) {
+	if n <= 0 {
+		return
+	}
+	s.bytesRead.Add(uint64(n))
+	s.totals.addReceived(n)
+	s.mem.read(s.s.StreamID(), n)
+	s.touch()
+}
+
+func (s *Stream) done() {
+	s.doneOnce.Do(func() {
+		s.ended.Store(true)
+		if s.onDone != nil {
+			s.onDone()
+		}
+	})
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+func (s *Stream) write(p []byte) (n int, err error) {
+	s.progress.begin()
+	defer s.progress.end()
+	if s.w != nil {
+		s.wlock.Lock()
+		n, err = s.w.Write(p)
+		s.wlock.Unlock()
+	} else {
+		n, err = s.dst.Write(p)
+	}
+	if n > 0 {
+		s.bytesWritten.Add(uint64(n))
+		s.totals.addSent(n)
+		s.touch()
+	}
+	if err != nil {
+		s.observeError(err)
+	}
+	return n, err
+}
+
+// Flush writes any buffered data to the underlying stream.
+// It is a no-op if the stream was opened without a send buffer.
+func (s *Stream) Flush() error {
+	if s.w == nil {
+		return nil
+	}
+	s.wlock.Lock()
+	defer s.wlock.Unlock()
+	return s.flushLocked()
+}
+
+// flushLocked writes the buffered data to quic-go. The write lock must be
+// held.
+func (s *Stream) flushLocked() error {
+	s.progress.begin()
+	defer s.progress.end()
+	return s.w.Flush()
+}
+
+// Buffered returns the number of bytes held in the stream's send buffer,
+// see WritableStream.Buffered.
+func (s *Stream) Buffered() int {
+	if s.w == nil {
+		return 0
+	}
+	s.wlock.Lock()
+	defer s.wlock.Unlock()
+	return s.w.Buffered()
+}
+
+// SendBufferSize returns the size of the stream's write buffer, or zero
+// if writes are not buffered.
+func (s *Stream) SendBufferSize() int {
+	if s.w == nil {
+		return 0
+	}
+	return s.w.Size()
+}
+
+// ReceiveBufferSize returns the size of the stream's read buffer, or zero
+// if reads are not buffered.
+func (s *Stream) ReceiveBufferSize() int {
+	if s.r == nil {
+		return 0
+	}
+	return s.r.Size()
+}
+
+// Priority returns the scheduling priority the stream was opened with.
+func (s *Stream) Priority() Priority {
+	return s.opts.Priority
+}
+
 // StreamID returns the ID of the QuicStream
 func (s *Stream) StreamID() uint64 {
 	return uint64(s.s.StreamID())
 }
 
+// Local reports whether the stream was opened by the local endpoint rather
+// than accepted from the peer.
+func (s *Stream) Local() bool {
+	return isLocalStream(s.s.StreamID(), s.client)
+}
+
+// isLocalStream reports whether stream id was opened by the endpoint with
+// the given perspective. The lowest bit of the ID is set for streams opened
+// by the server.
+func isLocalStream(id quic.StreamID, client bool) bool {
+	return (id&1 == 0) == client
+}
+
 // Close implements the Conn Close method. It is used to close
 // the connection. Any calls to Read and Write will be unblocked and return an error.
+// Buffered data is flushed before the stream is finished, unless the
+// stream was opened with StreamOptions.ResetOnClose, which resets both
+// directions. A stream returned by RoundTripStreaming also completes its
+// request.
 func (s *Stream) Close() error {
+	defer s.done()
+	if s.opts.ResetOnClose {
+		s.cancel(errorCodeCanceled)
+		return nil
+	}
+	return s.CloseWrite()
+}
+
+// CloseWrite flushes buffered data and sends FIN on the write side only.
+// Subsequent writes fail, while reads continue until the peer finishes
+// its side of the stream.
+func (s *Stream) CloseWrite() error {
+	if s.w == nil {
+		return s.s.Close()
+	}
+	s.wlock.Lock()
+	defer s.wlock.Unlock()
+	if err := s.flushLocked(); err != nil {
+		return err
+	}
 	return s.s.Close()
 }
 
+// BindContext ties the stream to ctx: once ctx is done, both directions of
+// the stream are reset with code, aborting pending reads and writes. The
+// binding ends when the write side is closed, normally with Close,
+// CloseWrite or a final WriteQuic, so a stream that completed is not reset
+// when ctx is canceled afterwards. It also ends by calling the returned
+// stop function, which reports whether it prevented the reset. No goroutine
+// is kept for the binding.
+func (s *Stream) BindContext(ctx context.Context, code uint16) (stop func() bool) {
+	stop = context.AfterFunc(ctx, func() {
+		if s.s.Context().Err() == nil {
+			s.cancel(quic.StreamErrorCode(code))
+		}
+	})
+	context.AfterFunc(s.s.Context(), func() { stop() })
+	return stop
+}
+
 // SetDeadline sets read and write deadlines associated with the stream. A zero value for t means Read and Write will not timeout.
 func (s *Stream) SetDeadline(t time.Time) error {
+	setWriteDeadline(s.dst, t)
 	return s.s.SetDeadline(t)
 }
 
@@ -0,0 +1,501 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLocalStream(t *testing.T) {
+	testCases := []struct {
+		id           quic.StreamID
+		client, want bool
+	}{
+		{0, true, true}, // client-initiated bidirectional
+		{0, false, false},
+		{1, true, false}, // server-initiated bidirectional
+		{1, false, true},
+		{6, true, true},  // client-initiated unidirectional
+		{7, false, true}, // server-initiated unidirectional
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		assert.Equal(t, tc.want, isLocalStream(tc.id, tc.client), "stream %d, client %v", tc.id, tc.client)
+	}
+}
+
+func TestStream_Local(t *testing.T) {
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+	testCases := []struct {
+		name         string
+		opener, peer *Session
+	}{
+		{"ClientOpened", client, server},
+		{"ServerOpened", server, client},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			opened, err := tc.opener.OpenStream()
+			if !assert.NoError(t, err) {
+				return
+			}
+			// The peer learns about the stream with its first data.
+			_, err = opened.Write([]byte{0}, false)
+			assert.NoError(t, err)
+
+			accepted, err := tc.peer.AcceptStream()
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, opened.StreamID(), accepted.StreamID())
+			assert.True(t, opened.Local())
+			assert.False(t, accepted.Local())
+		})
+	}
+}
+
+func TestStream_EmptyFin(t *testing.T) {
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+	testCases := []struct {
+		name string
+		opts StreamOptions
+	}{
+		{"Unbuffered", StreamOptions{}},
+		{"Buffered", StreamOptions{SendBufferSize: 4 << 10, ReceiveBufferSize: 4 << 10}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			str, err := client.OpenStreamOpts(ctx, tc.opts)
+			if !assert.NoError(t, err) {
+				return
+			}
+			n, err := str.WriteQuic(nil, true)
+			assert.NoError(t, err)
+			assert.Zero(t, n)
+
+			accepted, err := server.AcceptStream()
+			if !assert.NoError(t, err) {
+				return
+			}
+			n, fin, err := accepted.ReadQuic(make([]byte, 16))
+			assert.Zero(t, n)
+			assert.True(t, fin)
+			assert.ErrorIs(t, err, io.EOF)
+
+			uni, err := client.OpenUniStreamOpts(ctx, tc.opts)
+			if !assert.NoError(t, err) {
+				return
+			}
+			n, err = uni.WriteQuic(nil, true)
+			assert.NoError(t, err)
+			assert.Zero(t, n)
+
+			acceptedUni, err := server.AcceptUniStream()
+			if !assert.NoError(t, err) {
+				return
+			}
+			n, fin, err = acceptedUni.ReadQuic(make([]byte, 16))
+			assert.Zero(t, n)
+			assert.True(t, fin)
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestSession_StreamLimit(t *testing.T) {
+	const limit = 2
+
+	serverConfig := newTestConfig(t)
+	serverConfig.QUICConfigModifier = func(c *quic.Config) {
+		c.MaxIncomingStreams = limit
+		c.MaxIncomingUniStreams = limit
+	}
+	server, client := newTestSessions(t, serverConfig, newTestConfig(t))
+
+	testCases := []struct {
+		name string
+		// open opens a stream, blocking if ctx is not nil, and finishes it
+		open func(ctx context.Context) error
+		// accept accepts a stream and reads it to the end
+		accept func() error
+	}{
+		{
+			name: "Bidi",
+			open: func(ctx context.Context) error {
+				var str *Stream
+				var err error
+				if ctx != nil {
+					str, err = client.OpenStreamOpts(ctx, StreamOptions{})
+				} else {
+					str, err = client.OpenStream()
+				}
+				if err != nil {
+					return err
+				}
+				_, err = str.WriteQuic([]byte("request"), true)
+				return err
+			},
+			accept: func() error {
+				str, err := server.AcceptStream()
+				if err != nil {
+					return err
+				}
+				if _, err := io.ReadAll(str); err != nil {
+					return err
+				}
+				return str.CloseWrite()
+			},
+		},
+		{
+			name: "Uni",
+			open: func(ctx context.Context) error {
+				var str *WritableStream
+				var err error
+				if ctx != nil {
+					str, err = client.OpenUniStreamOpts(ctx, StreamOptions{})
+				} else {
+					str, err = client.OpenUniStream()
+				}
+				if err != nil {
+					return err
+				}
+				_, err = str.WriteQuic([]byte("request"), true)
+				return err
+			},
+			accept: func() error {
+				str, err := server.AcceptUniStream()
+				if err != nil {
+					return err
+				}
+				_, err = io.ReadAll(str)
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < limit; i++ {
+				assert.NoError(t, tc.open(nil))
+			}
+			var limitErr *quic.StreamLimitReachedError
+			assert.ErrorAs(t, tc.open(nil), &limitErr)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			opened := make(chan error, 1)
+			go func() { opened <- tc.open(ctx) }()
+
+			select {
+			case err := <-opened:
+				t.Fatalf("opened beyond the stream limit: %v", err)
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			// Completing the streams makes the server raise the limit.
+			for i := 0; i < limit; i++ {
+				assert.NoError(t, tc.accept())
+			}
+			assert.NoError(t, <-opened)
+			assert.Eventually(t, func() bool {
+				return tc.open(nil) == nil
+			}, 5*time.Second, 10*time.Millisecond)
+
+			for i := 0; i < limit; i++ {
+				assert.NoError(t, tc.accept())
+			}
+		})
+	}
+}
+
+func TestStream_CloseBehavior(t *testing.T) {
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+	data := []byte("buffered data")
+
+	testCases := []struct {
+		name  string
+		reset bool
+	}{
+		{"Flush", false},
+		{"Reset", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		check := func(t *testing.T, received []byte, err error) {
+			if tc.reset {
+				var streamErr *quic.StreamError
+				if assert.ErrorAs(t, err, &streamErr) {
+					assert.Equal(t, errorCodeCanceled, streamErr.ErrorCode)
+					assert.True(t, streamErr.Remote)
+				}
+				assert.Empty(t, received)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, data, received)
+		}
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			opts := StreamOptions{SendBufferSize: 4 << 10, ResetOnClose: tc.reset}
+
+			str, err := client.OpenStreamOpts(ctx, opts)
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = str.Write(data, false)
+			assert.NoError(t, err)
+			assert.Equal(t, len(data), str.Buffered())
+			assert.NoError(t, str.Close())
+
+			accepted, err := server.AcceptStream()
+			if !assert.NoError(t, err) {
+				return
+			}
+			received, err := io.ReadAll(accepted)
+			check(t, received, err)
+
+			uni, err := client.OpenUniStreamOpts(ctx, opts)
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = uni.Write(data, false)
+			assert.NoError(t, err)
+			assert.Equal(t, len(data), uni.Buffered())
+			assert.NoError(t, uni.Close())
+
+			acceptedUni, err := server.AcceptUniStream()
+			if !assert.NoError(t, err) {
+				return
+			}
+			received, err = io.ReadAll(acceptedUni)
+			check(t, received, err)
+		})
+	}
+}
+
+func TestStream_BindContext(t *testing.T) {
+	const code = 7
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+
+	testCases := []struct {
+		name string
+		// complete finishes the server's write side before ctx is canceled
+		complete bool
+	}{
+		{"Canceled", false},
+		{"Completed", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			str, err := client.OpenStreamOpts(context.Background(), StreamOptions{})
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = str.WriteQuic([]byte("request"), false)
+			assert.NoError(t, err)
+
+			accepted, err := server.AcceptStream()
+			if !assert.NoError(t, err) {
+				return
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			accepted.BindContext(ctx, code)
+			if tc.complete {
+				_, err = accepted.WriteQuic([]byte("response"), true)
+				assert.NoError(t, err)
+			}
+			cancel()
+
+			response, err := io.ReadAll(str)
+			if tc.complete {
+				assert.NoError(t, err)
+				assert.Equal(t, "response", string(response))
+
+				// the read side is not reset either
+				request := make([]byte, 16)
+				n, err := accepted.Read(request)
+				assert.NoError(t, err)
+				assert.Equal(t, "request", string(request[:n]))
+				return
+			}
+			var streamErr *quic.StreamError
+			if assert.ErrorAs(t, err, &streamErr) {
+				assert.Equal(t, quic.StreamErrorCode(code), streamErr.ErrorCode)
+				assert.True(t, streamErr.Remote)
+			}
+			_, err = accepted.Read(make([]byte, 16))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWritableStream_Writable(t *testing.T) {
+	const window = 16 << 10
+
+	serverConfig := newTestConfig(t)
+	serverConfig.QUICConfigModifier = func(c *quic.Config) {
+		c.InitialStreamReceiveWindow = window
+		c.MaxStreamReceiveWindow = window
+	}
+	server, client := newTestSessions(t, serverConfig, newTestConfig(t))
+
+	str, err := client.OpenUniStream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	writable := str.Writable()
+	select {
+	case <-writable:
+	case <-time.After(time.Second):
+		t.Fatal("new stream not writable")
+	}
+
+	// Fill the peer's window.
+	_, err = str.Write(make([]byte, window), false)
+	assert.NoError(t, err)
+	select {
+	case <-writable:
+		t.Fatal("writable with the peer's window full")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	accepted, err := server.AcceptUniStream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = io.ReadFull(accepted, make([]byte, window))
+	assert.NoError(t, err)
+	select {
+	case <-writable:
+	case <-time.After(5 * time.Second):
+		t.Fatal("not writable after the peer read")
+	}
+}
+
+// TestStream_FlushWhileWriting flushes a buffered stream from another
+// goroutine than the one writing, as for a periodic flush.
+func TestStream_FlushWhileWriting(t *testing.T) {
+	const writes, size = 256, 1000
+
+	server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+	str, err := client.OpenStreamOpts(context.Background(), StreamOptions{SendBufferSize: 4 << 10})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for i := 0; i < writes; i++ {
+			if _, err := str.Write(make([]byte, size), false); err != nil {
+				return
+			}
+		}
+	}()
+	for flushing := true; flushing; {
+		select {
+		case <-written:
+			flushing = false
+		default:
+			assert.NoError(t, str.Flush())
+			_ = str.Buffered()
+		}
+	}
+	assert.NoError(t, str.Close())
+
+	accepted, err := server.AcceptStream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err := io.ReadAll(accepted)
+	assert.NoError(t, err)
+	assert.Len(t, data, writes*size)
+}
+
+// blockablePacketConn drops all packets it receives once blocked.
+type blockablePacketConn struct {
+	net.PacketConn
+	blocked atomic.Bool
+}
+
+func (c *blockablePacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	for {
+		n, addr, err := c.PacketConn.ReadFrom(p)
+		if err != nil || !c.blocked.Load() {
+			return n, addr, err
+		}
+	}
+}
+
+// TestWritableStream_WaitDrained_SessionClosed shows that WaitDrained on a
+// finished stream returns once the session ends without its data being
+// acknowledged.
+func TestWritableStream_WaitDrained_SessionClosed(t *testing.T) {
+	l, err := Listen("127.0.0.1:0", newTestConfig(t))
+	if !assert.NoError(t, err) {
+		return
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		s, err := l.Accept()
+		if err == nil {
+			t.Cleanup(func() { _ = s.Close() })
+		}
+	}()
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	pc := &blockablePacketConn{PacketConn: udp}
+	tr := NewTransport(pc)
+	t.Cleanup(func() { _ = tr.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := tr.Dial(ctx, l.l.Addr().String(), newTestConfig(t))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	str, err := client.OpenUniStreamOpts(ctx, StreamOptions{TrackAcks: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	pc.blocked.Store(true) // no acknowledgements arrive anymore
+	_, err = str.WriteQuic([]byte("data"), true)
+	assert.NoError(t, err)
+
+	drained := make(chan error, 1)
+	go func() { drained <- str.WaitDrained(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, client.CloseWithError(7, errors.New("done")))
+
+	var appErr *quic.ApplicationError
+	if assert.ErrorAs(t, <-drained, &appErr) {
+		assert.Equal(t, quic.ApplicationErrorCode(7), appErr.ErrorCode)
+	}
+}
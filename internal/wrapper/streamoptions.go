@@ -0,0 +1,65 @@
+package wrapper
+
+import "time"
+
+// Priority is the scheduling priority of a stream.
+type Priority int
+
+// Bounds applied to the per-stream buffer sizes of StreamOptions.
+const (
+	minStreamBufferSize = 512
+	maxStreamBufferSize = 1 << 20 // 1 MB
+)
+
+// StreamOptions holds the per-stream tuning knobs used by OpenStreamOpts.
+// Zero values select the defaults.
+//
+// The buffers are kept by the wrapper in front of the quic-go stream and
+// are sized independently for each direction. They do not change quic-go's
+// flow control: the receive window of every stream is still governed by the
+// session's MaxStreamReceiveWindow and shares the connection-level window
+// with all other streams. Sizes are clamped to [512 B, 1 MB].
+type StreamOptions struct {
+	// SendBufferSize is the size of the write buffer placed in front of the
+	// stream. Writes are coalesced until the buffer is full, the stream is
+	// flushed or the final frame is written. Zero disables buffering.
+	// Buffered data is not sent on its own: the stream must be flushed,
+	// closed or finished, which may happen from another goroutine than
+	// the writes.
+	SendBufferSize int
+	// ReceiveBufferSize is the size of the read buffer placed in front of
+	// the stream, allowing small reads without a call into quic-go each.
+	// Zero disables buffering.
+	ReceiveBufferSize int
+	// Priority is the priority class of the stream's writes. Writes of a
+	// lower class wait while writes of a higher class are in progress on
+	// the same session, see PriorityHigh.
+	Priority Priority
+	// IdleTimeout, if non-zero, resets the stream once no bytes were read
+	// or written for this long, see OpenStreamWithIdleTimeout.
+	IdleTimeout time.Duration
+	// IdleResetCode is the error code of the reset caused by IdleTimeout.
+	// Zero selects error code 2, as used for Config.StreamIdleTimeout.
+	IdleResetCode uint16
+	// ResetOnClose makes Close reset the stream with error code 0,
+	// discarding the data not sent yet, instead of flushing it and then
+	// finishing the stream. A bidirectional stream also stops reading.
+	// Finishing the stream with WriteQuic or CloseWrite always flushes.
+	ResetOnClose bool
+	// TrackAcks makes the session collect the acknowledgements of the
+	// data of a unidirectional stream, which WritableStream.WaitDrained
+	// requires. It costs bookkeeping for every packet carrying the
+	// stream's data, so it is off by default.
+	TrackAcks bool
+}
+
+func clampStreamBufferSize(n int) int {
+	switch {
+	case n < minStreamBufferSize:
+		return minStreamBufferSize
+	case n > maxStreamBufferSize:
+		return maxStreamBufferSize
+	default:
+		return n
+	}
+}
@@ -0,0 +1,44 @@
+package wrapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamOptions_BufferSizes(t *testing.T) {
+	for name, tc := range map[string]struct {
+		opts          StreamOptions
+		send, receive int
+	}{
+		"Defaults": {
+			opts: StreamOptions{},
+		},
+		"Independent": {
+			opts:    StreamOptions{SendBufferSize: 64 << 10, ReceiveBufferSize: 4 << 10},
+			send:    64 << 10,
+			receive: 4 << 10,
+		},
+		"SendOnly": {
+			opts: StreamOptions{SendBufferSize: 8 << 10},
+			send: 8 << 10,
+		},
+		"ClampedLow": {
+			opts:    StreamOptions{SendBufferSize: 1, ReceiveBufferSize: 100},
+			send:    minStreamBufferSize,
+			receive: minStreamBufferSize,
+		},
+		"ClampedHigh": {
+			opts:    StreamOptions{SendBufferSize: 1 << 30, ReceiveBufferSize: 1 << 30},
+			send:    maxStreamBufferSize,
+			receive: maxStreamBufferSize,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			s := newStream(nil, tc.opts)
+			assert.Equal(t, tc.send, s.SendBufferSize())
+			assert.Equal(t, tc.receive, s.ReceiveBufferSize())
+		})
+	}
+}
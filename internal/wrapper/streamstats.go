@@ -0,0 +1,39 @@
+package wrapper
+
+import (
+	"errors"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// StreamStats holds the counters of a single stream.
+type StreamStats struct {
+	// BytesRead is the number of bytes returned by reads.
+	BytesRead uint64
+	// BytesWritten is the number of bytes accepted by writes, including
+	// data still held in the stream's send buffer.
+	BytesWritten uint64
+	// Reset is true if either direction of the stream was reset, locally
+	// or by the peer.
+	Reset bool
+}
+
+// Stats returns the stream's counters. They are maintained by the wrapper
+// because quic-go does not keep per-stream metrics; retransmissions are
+// only accounted for per connection and are therefore not reported here.
+// Stats is cheap and safe to call concurrently with reads and writes.
+func (s *Stream) Stats() StreamStats {
+	return StreamStats{
+		BytesRead:    s.bytesRead.Load(),
+		BytesWritten: s.bytesWritten.Load(),
+		Reset:        s.reset.Load(),
+	}
+}
+
+// observeError records resets reported through read and write errors.
+func (s *Stream) observeError(err error) {
+	var streamErr *quic.StreamError
+	if errors.As(err, &streamErr) {
+		s.reset.Store(true)
+	}
+}
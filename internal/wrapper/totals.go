@@ -0,0 +1,36 @@
+package wrapper
+
+import "sync/atomic"
+
+// byteTotals counts the application bytes of a session.
+type byteTotals struct {
+	sent     atomic.Uint64
+	received atomic.Uint64
+}
+
+func (t *byteTotals) addSent(n int) {
+	if t != nil && n > 0 {
+		t.sent.Add(uint64(n))
+	}
+}
+
+func (t *byteTotals) addReceived(n int) {
+	if t != nil && n > 0 {
+		t.received.Add(uint64(n))
+	}
+}
+
+// TotalBytesSent returns the number of application bytes written to the
+// session's streams and datagrams. Bytes held in a send buffer count as
+// sent. Framing and retransmissions are not included, so the number of
+// bytes on the wire is higher.
+func (s *Session) TotalBytesSent() uint64 {
+	return s.totals.sent.Load()
+}
+
+// TotalBytesReceived returns the number of application bytes read from the
+// session's streams and datagrams. Like TotalBytesSent it excludes framing
+// and duplicates.
+func (s *Session) TotalBytesReceived() uint64 {
+	return s.totals.received.Load()
+}
@@ -0,0 +1,253 @@
+package wrapper
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/logging"
+)
+
+// tracer collects the connection events reported by quic-go that are
+// not exposed on the connection itself.
+type tracer struct {
+	lock        sync.Mutex
+	localParams *logging.TransportParameters
+	peerParams  *logging.TransportParameters
+	retried     bool
+	offered0RTT bool
+	clientAuth  bool // the server requested the client's certificate
+
+	offeredProtocols []string // ALPN protocols offered by the client
+
+	connectedAt time.Time // the connection was created by quic-go
+	completedAt time.Time // the handshake completed
+
+	congestionWindow logging.ByteCount
+	bytesInFlight    logging.ByteCount
+	smoothedRTT      time.Duration
+	flowBlocked      bool // we announced being blocked by the peer's flow control
+
+	// the peer's connection flow control limit, see Writable
+	maxData     logging.ByteCount // largest MAX_DATA received
+	dataBlocked logging.ByteCount // limit we last announced being blocked at
+
+	// acknowledgements of the streams waited for by WaitDrained
+	watched     map[logging.StreamID]*streamAcks
+	sentData    map[logging.PacketNumber][]sentStreamData
+	acksChanged chan struct{} // closed and replaced when data was acknowledged
+
+	sending map[logging.StreamID]*sendProgress // streams checked by StarvedStreams
+
+	pathCheck atomic.Pointer[func()] // called for every 1-RTT packet, see OnPathChange
+
+	earlyData atomic.Int64 // STREAM bytes received in 0-RTT packets
+
+	peerStreams streamCounts // streams seen in received frames, see PendingStreams
+
+	events *eventQueue // of the session, if enabled
+
+	mem *memoryTracker // nil unless Config.MaxSessionMemory is set
+}
+
+func newTracer(config *Config) *tracer {
+	t := &tracer{}
+	if config.MaxSessionMemory > 0 {
+		t.mem = newMemoryTracker(config.MaxSessionMemory)
+	}
+	return t
+}
+
+func (t *tracer) connectionTracer() *logging.ConnectionTracer {
+	t.lock.Lock()
+	t.connectedAt = time.Now()
+	t.lock.Unlock()
+
+	return &logging.ConnectionTracer{
+		ChoseALPN: func(string) {
+			// called right before quic-go signals the handshake completion
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.completedAt = time.Now()
+		},
+		SentTransportParameters: func(p *logging.TransportParameters) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.localParams = p
+		},
+		ReceivedTransportParameters: func(p *logging.TransportParameters) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.peerParams = p
+		},
+		RestoredTransportParameters: func(*logging.TransportParameters) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.offered0RTT = true
+		},
+		ReceivedRetry: func(*logging.Header) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.retried = true
+		},
+		UpdatedMetrics: func(rtt *logging.RTTStats, cwnd, bytesInFlight logging.ByteCount, _ int) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			t.smoothedRTT = rtt.SmoothedRTT()
+			t.congestionWindow = cwnd
+			t.bytesInFlight = bytesInFlight
+		},
+		SentShortHeaderPacket: func(hdr *logging.ShortHeader, _ logging.ByteCount, _ logging.ECN, _ *logging.AckFrame, frames []logging.Frame) {
+			t.sentPacket(hdr.PacketNumber, frames)
+			t.sentStreamFrames(frames)
+			t.checkPath()
+			for _, f := range frames {
+				switch f.(type) {
+				case *logging.DataBlockedFrame, *logging.StreamDataBlockedFrame:
+					t.setFlowBlocked(true)
+				}
+				if f, ok := f.(*logging.DataBlockedFrame); ok {
+					t.sentDataBlocked(f.MaximumData)
+				}
+			}
+		},
+		ReceivedLongHeaderPacket: func(hdr *logging.ExtendedHeader, _ logging.ByteCount, _ logging.ECN, frames []logging.Frame) {
+			t.receivedFrames(frames)
+			if logging.PacketTypeFromHeader(&hdr.Header) == logging.PacketType0RTT {
+				t.receivedEarlyData(frames)
+			}
+		},
+		ReceivedShortHeaderPacket: func(_ *logging.ShortHeader, _ logging.ByteCount, _ logging.ECN, frames []logging.Frame) {
+			t.receivedFrames(frames)
+			t.checkPath()
+		},
+		UpdatedKey: func(logging.KeyPhase, bool) {
+			t.lock.Lock()
+			events := t.events
+			t.lock.Unlock()
+			events.emit(Event{Type: EventKeyUpdate})
+		},
+		AcknowledgedPacket: func(encLevel logging.EncryptionLevel, pn logging.PacketNumber) {
+			if encLevel == logging.Encryption1RTT {
+				t.ackedPacket(pn, true)
+			}
+		},
+		LostPacket: func(encLevel logging.EncryptionLevel, pn logging.PacketNumber, _ logging.PacketLossReason) {
+			if encLevel == logging.Encryption1RTT {
+				t.ackedPacket(pn, false)
+			}
+		},
+	}
+}
+
+func (t *tracer) receivedFrames(frames []logging.Frame) {
+	for _, f := range frames {
+		switch f := f.(type) {
+		case *logging.MaxDataFrame:
+			t.setFlowBlocked(false)
+			t.receivedMaxData(f.MaximumData)
+		case *logging.MaxStreamDataFrame:
+			t.setFlowBlocked(false)
+			t.receivedMaxStreamData(f.StreamID, f.MaximumStreamData)
+		case *logging.StreamFrame:
+			t.mem.received(f.StreamID, f.Offset+f.Length, f.Fin)
+			t.peerStreams.add(f.StreamID)
+		case *logging.ResetStreamFrame:
+			t.mem.reset(f.StreamID)
+			t.peerStreams.add(f.StreamID)
+		case *logging.StreamDataBlockedFrame:
+			t.peerStreams.add(f.StreamID)
+		case *logging.StopSendingFrame:
+			t.peerStreams.add(f.StreamID)
+		}
+	}
+}
+
+func (t *tracer) setFlowBlocked(blocked bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.flowBlocked = blocked
+}
+
+// tracerFunc is the type of quic.Config's Tracer.
+type tracerFunc = func(context.Context, logging.Perspective, quic.ConnectionID) *logging.ConnectionTracer
+
+// combineTracers returns a tracerFunc reporting to both a and b.
+// b may be nil.
+func combineTracers(a, b tracerFunc) tracerFunc {
+	if b == nil {
+		return a
+	}
+	return func(ctx context.Context, p logging.Perspective, id quic.ConnectionID) *logging.ConnectionTracer {
+		return logging.NewMultiplexedConnectionTracer(a(ctx, p, id), b(ctx, p, id))
+	}
+}
+
+// newClientTracer returns a quic.Config Tracer reporting to t.
+func newClientTracer(t *tracer) tracerFunc {
+	return func(context.Context, logging.Perspective, quic.ConnectionID) *logging.ConnectionTracer {
+		return t.connectionTracer()
+	}
+}
+
+// tracerRegistry hands out a tracer per incoming connection and keeps it
+// until the connection is accepted.
+type tracerRegistry struct {
+	config  *Config
+	lock    sync.Mutex
+	tracers map[quic.ConnectionTracingID]*tracer
+
+	rateLimited atomic.Uint64 // connections refused by the handshake rate limit
+	handshakes  atomic.Int64  // connections still handshaking
+}
+
+func newTracerRegistry(config *Config) *tracerRegistry {
+	return &tracerRegistry{config: config, tracers: make(map[quic.ConnectionTracingID]*tracer)}
+}
+
+// newTracer is used as quic.Config Tracer on the server side.
+func (r *tracerRegistry) newTracer(ctx context.Context, _ logging.Perspective, _ quic.ConnectionID) *logging.ConnectionTracer {
+	id, _ := ctx.Value(quic.ConnectionTracingKey).(quic.ConnectionTracingID)
+	t := newTracer(r.config)
+
+	r.lock.Lock()
+	r.tracers[id] = t
+	r.lock.Unlock()
+
+	r.handshakes.Add(1)
+	handshakeDone := sync.OnceFunc(func() { r.handshakes.Add(-1) })
+
+	ct := t.connectionTracer()
+	choseALPN := ct.ChoseALPN
+	ct.ChoseALPN = func(protocol string) {
+		choseALPN(protocol)
+		handshakeDone()
+	}
+	ct.Close = func() {
+		handshakeDone()
+
+		// Drop connections that were never accepted.
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if r.tracers[id] == t {
+			delete(r.tracers, id)
+		}
+	}
+	return ct
+}
+
+// take removes and returns the tracer of an accepted connection.
+func (r *tracerRegistry) take(conn *quic.Conn) *tracer {
+	id, _ := conn.Context().Value(quic.ConnectionTracingKey).(quic.ConnectionTracingID)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	t, ok := r.tracers[id]
+	if !ok {
+		return &tracer{}
+	}
+	delete(r.tracers, id)
+	return t
+}
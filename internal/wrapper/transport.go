@@ -0,0 +1,130 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// Transport dials multiple sessions over a single UDP socket.
+//
+// Sessions sharing a Transport share the socket and its local port only.
+// quic-go keeps congestion control, RTT estimation and flow control per
+// connection and does not coalesce connections, so several sessions to the
+// same host each probe the path independently and compete with each other
+// like separate connections would. Applications sending to one host are
+// better served by multiplexing streams over a single session.
+type Transport struct {
+	tr *quic.Transport
+	pc net.PacketConn
+
+	lock     sync.Mutex
+	slots    chan struct{} // semaphore bounding the sessions, nil if unlimited
+	block    bool
+	sessions atomic.Int64
+}
+
+// ErrTooManySessions is returned by Transport.Dial when the session limit
+// is reached and the Transport is not configured to wait for a free slot.
+var ErrTooManySessions = errors.New("quic: too many sessions on transport")
+
+// NewTransport creates a Transport sending and receiving on pc.
+// The Transport does not close pc.
+func NewTransport(pc net.PacketConn) *Transport {
+	return &Transport{
+		tr: &quic.Transport{Conn: pc},
+		pc: pc,
+	}
+}
+
+// Dial dials the address over quic using the Transport's socket.
+// The handshake is aborted when ctx is done.
+func (t *Transport) Dial(ctx context.Context, addr string, config *Config) (*Session, error) {
+	release, err := t.acquireSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := dialAddr(ctx, addr, config, t.tr.Dial)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	session.pc = t.pc
+	go func() {
+		<-session.s.Context().Done()
+		release()
+	}()
+	return session, nil
+}
+
+// SetMaxSessions limits the number of sessions the Transport keeps open at
+// once, counting handshakes in progress. At the limit, Dial blocks until a
+// session closes or its context is done if block is set, and fails with
+// ErrTooManySessions otherwise. A value of n <= 0 removes the limit.
+// Sessions already open keep counting against the limit in effect when
+// they were dialed.
+func (t *Transport) SetMaxSessions(n int, block bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.block = block
+	if n <= 0 {
+		t.slots = nil
+		return
+	}
+	t.slots = make(chan struct{}, n)
+}
+
+// Sessions returns the number of sessions currently open or being dialed
+// over the Transport.
+func (t *Transport) Sessions() int {
+	return int(t.sessions.Load())
+}
+
+func (t *Transport) acquireSession(ctx context.Context) (release func(), err error) {
+	t.lock.Lock()
+	slots, block := t.slots, t.block
+	t.lock.Unlock()
+
+	if slots != nil {
+		if block {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		} else {
+			select {
+			case slots <- struct{}{}:
+			default:
+				return nil, ErrTooManySessions
+			}
+		}
+	}
+
+	t.sessions.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.sessions.Add(-1)
+			if slots != nil {
+				<-slots
+			}
+		})
+	}, nil
+}
+
+// LocalAddr returns the local address of the Transport's socket.
+func (t *Transport) LocalAddr() net.Addr {
+	return t.pc.LocalAddr()
+}
+
+// Close closes all sessions dialed over the Transport.
+func (t *Transport) Close() error {
+	return t.tr.Close()
+}
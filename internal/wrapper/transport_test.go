@@ -0,0 +1,84 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport_MaxSessions(t *testing.T) {
+	testCases := []struct {
+		name  string
+		block bool
+	}{
+		{"Error", false},
+		{"Block", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := Listen("127.0.0.1:0", newTestConfig(t))
+			if !assert.NoError(t, err) {
+				return
+			}
+			t.Cleanup(func() { _ = l.Close() })
+			go func() {
+				for {
+					s, err := l.Accept()
+					if err != nil {
+						return
+					}
+					t.Cleanup(func() { _ = s.Close() })
+				}
+			}()
+
+			pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if !assert.NoError(t, err) {
+				return
+			}
+			t.Cleanup(func() { _ = pc.Close() })
+			tr := NewTransport(pc)
+			t.Cleanup(func() { _ = tr.Close() })
+			tr.SetMaxSessions(1, tc.block)
+			addr := l.l.Addr().String()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			first, err := tr.Dial(ctx, addr, newTestConfig(t))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, 1, tr.Sessions())
+
+			// The limit is reached.
+			if tc.block {
+				waitCtx, stop := context.WithTimeout(ctx, 50*time.Millisecond)
+				_, err = tr.Dial(waitCtx, addr, newTestConfig(t))
+				stop()
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			} else {
+				_, err = tr.Dial(ctx, addr, newTestConfig(t))
+				assert.ErrorIs(t, err, ErrTooManySessions)
+			}
+			assert.Equal(t, 1, tr.Sessions())
+
+			// Closing the session frees its slot, which a blocked Dial takes
+			// right away.
+			assert.NoError(t, first.Close())
+			if !tc.block {
+				assert.Eventually(t, func() bool { return tr.Sessions() == 0 }, time.Second, 10*time.Millisecond)
+			}
+			second, err := tr.Dial(ctx, addr, newTestConfig(t))
+			if assert.NoError(t, err) {
+				assert.NoError(t, second.Close())
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package wrapper
+
+import (
+	"context"
+	"net"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// ClientUDP establishes a QUIC session to raddr over conn. Unlike Client,
+// which adapts a connected net.Conn, the socket is passed to quic-go as is,
+// so quic-go can use ECN, GSO and the packet addresses, which connection
+// migration requires. The session does not close conn; it may be shared
+// with other sessions or listeners only through a Transport.
+func ClientUDP(conn *net.UDPConn, raddr net.Addr, config *Config) (*Session, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if raddr == nil {
+		return nil, errClientWithoutRemoteAddress
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t := newTracer(config)
+	quicConfig := getQuicConfig(config, newClientTracer(t))
+	checkReceiveBuffer(conn, config)
+
+	s, err := quic.Dial(ctx, quicSocket(conn, config), raddr, getClientTLSConfig(config, t), quicConfig)
+	if err != nil {
+		return nil, versionNegotiationError(err)
+	}
+	session := newSession(s, t, true, config)
+	session.pc = conn
+	return session, nil
+}
+
+// ServerUDP creates a listener for incoming QUIC sessions on conn. Like
+// ClientUDP, it passes the socket to quic-go as is, unlike Server. Closing
+// the listener does not close conn.
+func ServerUDP(conn *net.UDPConn, config *Config) (*Listener, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	tracers := newTracerRegistry(config)
+	quicConfig := getQuicConfig(config, tracers.newTracer)
+	tracers.limitIncoming(quicConfig, config)
+	checkReceiveBuffer(conn, config)
+
+	l, err := listen(quicSocket(conn, config), getTLSConfig(config), quicConfig, config, tracers)
+	if err != nil {
+		return nil, err
+	}
+	return newListener(l, tracers, conn, false, config), nil
+}
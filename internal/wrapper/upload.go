@@ -0,0 +1,303 @@
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// Limits of resumable uploads.
+const (
+	defaultUploadResumes = 5
+	maxUploadIDSize      = 1 << 10 // 1 kB
+	uploadCheckpointSize = 1 << 20 // 1 MB, confirmed by ReceiveUpload at a time
+	uploadChunkSize      = 32 << 10
+)
+
+var (
+	errUploadWithoutDial = errors.New("quic: upload without Dial function")
+	errUploadSize        = errors.New("quic: negative upload size")
+	errUploadProtocol    = errors.New("quic: upload protocol violation")
+	errUploadStoreOffset = errors.New("quic: upload store resumed beyond the upload size")
+)
+
+// UploadOptions configure Upload.
+type UploadOptions struct {
+	// Dial returns the session the upload runs on. It is called when the
+	// upload starts and again after the session was lost, so it usually
+	// wraps DialWithRetry or Pool.Get. Upload does not close the sessions
+	// it returns.
+	Dial func(context.Context) (*Session, error)
+	// MaxResumes is the number of times the upload resumes on a new
+	// session after the previous one was lost. Zero selects 5.
+	MaxResumes int
+	// Checkpoint, if set, is called with every offset the server confirmed
+	// as stored, starting with the offset the upload resumes from.
+	Checkpoint func(offset int64)
+}
+
+// Upload sends the first size bytes of src as upload id to a server
+// running ReceiveUpload on the stream. If the session is lost, Upload
+// dials a new one with opts.Dial and resumes from the offset the server
+// confirms to have stored, so data already stored is not sent again. It
+// returns nil once the server confirmed storing all size bytes.
+//
+// Resuming requires the server's cooperation: it has to keep the data of
+// an interrupted upload under its id and report how much of it is stored,
+// see UploadStore. Failures other than a lost session, like the server
+// rejecting the upload or src failing, end the upload right away, as does
+// ctx being done.
+//
+// The upload runs on a bidirectional stream:
+//  1. The client sends the length of id as varint, id and size as varint.
+//  2. The server replies with the offset to resume from as varint.
+//  3. The client sends the bytes of src from that offset up to size and
+//     finishes the stream.
+//  4. The server sends each offset up to which it stored the data as
+//     varint, ending with size, and finishes the stream.
+func Upload(ctx context.Context, id string, src io.ReaderAt, size int64, opts UploadOptions) error {
+	if opts.Dial == nil {
+		return errUploadWithoutDial
+	}
+	if size < 0 {
+		return errUploadSize
+	}
+	resumes := opts.MaxResumes
+	if resumes <= 0 {
+		resumes = defaultUploadResumes
+	}
+
+	for attempt := 0; ; attempt++ {
+		s, err := opts.Dial(ctx)
+		if err != nil {
+			return contextError(ctx, err)
+		}
+		err = s.upload(ctx, id, src, size, opts.Checkpoint)
+		if err == nil || ctx.Err() != nil || attempt >= resumes || !s.IsClosed() {
+			return contextError(ctx, err)
+		}
+	}
+}
+
+// upload runs a single attempt of Upload on s.
+func (s *Session) upload(ctx context.Context, id string, src io.ReaderAt, size int64, checkpoint func(int64)) error {
+	str, err := s.OpenStreamOpts(ctx, StreamOptions{})
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		str.cancel(errorCodeCanceled)
+	})
+	defer stop()
+
+	header := quicvarint.Append(nil, uint64(len(id)))
+	header = append(header, id...)
+	header = quicvarint.Append(header, uint64(size))
+	if _, err := str.WriteQuic(header, false); err != nil {
+		str.cancel(errorCodeCanceled)
+		return err
+	}
+	if err := str.Flush(); err != nil {
+		str.cancel(errorCodeCanceled)
+		return err
+	}
+
+	r := quicvarint.NewReader(str)
+	offset, err := readUploadOffset(r, 0, size)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		str.cancel(errorCodeCanceled)
+		return err
+	}
+	if checkpoint != nil {
+		checkpoint(offset)
+	}
+
+	confirmed := make(chan error, 1)
+	go func() {
+		confirmed <- readUploadCheckpoints(r, offset, size, checkpoint)
+	}()
+
+	if err := sendUploadData(str, src, offset, size); err != nil {
+		str.cancel(errorCodeCanceled)
+		<-confirmed
+		return err
+	}
+	err = <-confirmed
+	if err != nil {
+		str.cancel(errorCodeCanceled)
+	}
+	return err
+}
+
+// sendUploadData writes src from offset up to size to str and finishes it.
+func sendUploadData(str *Stream, src io.ReaderAt, offset, size int64) error {
+	data := io.NewSectionReader(src, offset, size-offset)
+	buf := make([]byte, uploadChunkSize)
+	for offset < size {
+		n, err := data.Read(buf)
+		if n > 0 {
+			if _, err := str.WriteQuic(buf[:n], false); err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+		if errors.Is(err, io.EOF) && offset < size {
+			return io.ErrUnexpectedEOF // src is shorter than size
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	}
+	return str.CloseWrite()
+}
+
+// readUploadCheckpoints reads the offsets confirmed by the server after
+// last until it finishes the stream, which it may only do once it
+// confirmed size.
+func readUploadCheckpoints(r quicvarint.Reader, last, size int64, checkpoint func(int64)) error {
+	for {
+		offset, err := readUploadOffset(r, last, size)
+		if errors.Is(err, io.EOF) {
+			if last < size {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		last = offset
+		if checkpoint != nil {
+			checkpoint(offset)
+		}
+	}
+}
+
+// readUploadOffset reads an offset sent by the server, which has to lie
+// between from and size.
+func readUploadOffset(r quicvarint.Reader, from, size int64) (int64, error) {
+	v, err := quicvarint.Read(r)
+	if err != nil {
+		return 0, err
+	}
+	if v < uint64(from) || v > uint64(size) {
+		return 0, errUploadProtocol
+	}
+	return int64(v), nil
+}
+
+// UploadStore keeps the data of the uploads received by ReceiveUpload.
+type UploadStore interface {
+	// Resume returns the number of bytes of upload id stored so far and a
+	// writer appending to them. size is the total size of the upload
+	// announced by the client. An error rejects the upload.
+	//
+	// If the writer has a Sync method, like *os.File, it is called before
+	// the stored data is confirmed to the client. If it is an io.Closer, it
+	// is closed when ReceiveUpload returns.
+	Resume(id string, size int64) (offset int64, w io.Writer, err error)
+}
+
+// ReceiveUpload receives an upload sent by Upload on str, storing its data
+// in store. It confirms the stored data to the client every megabyte and
+// once the upload is complete, then finishes str. It returns the id of
+// the upload, which the caller processes once the error is nil.
+//
+// An upload interrupted by a failure on either side can be resumed by the
+// client from the last offset store reports for it, so store has to keep
+// incomplete uploads until the client gives up on them. On failure, both
+// directions of str are reset; the client sees error code 1 if store
+// rejected the upload or failed to store its data.
+func ReceiveUpload(str *Stream, store UploadStore) (string, error) {
+	id, size, err := readUploadHeader(str)
+	if err != nil {
+		str.cancel(errorCodeCanceled)
+		return "", err
+	}
+
+	offset, w, err := store.Resume(id, size)
+	if err != nil {
+		str.cancel(errorCodeInternal)
+		return id, err
+	}
+	if c, ok := w.(io.Closer); ok {
+		defer c.Close()
+	}
+	if offset < 0 || offset > size {
+		str.cancel(errorCodeInternal)
+		return id, errUploadStoreOffset
+	}
+	if err := sendUploadOffset(str, offset); err != nil {
+		str.cancel(errorCodeCanceled)
+		return id, err
+	}
+
+	for offset < size {
+		n, err := io.CopyN(w, str, min(uploadCheckpointSize, size-offset))
+		offset += n
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == nil {
+			err = syncUpload(w)
+		}
+		if err == nil {
+			err = sendUploadOffset(str, offset)
+		}
+		if err != nil {
+			str.cancel(errorCodeInternal)
+			return id, err
+		}
+	}
+
+	// The client finishes the stream right after the data.
+	var extra [1]byte
+	if n, err := str.Read(extra[:]); n > 0 || !errors.Is(err, io.EOF) {
+		str.cancel(errorCodeCanceled)
+		if err == nil || errors.Is(err, io.EOF) {
+			err = errUploadProtocol
+		}
+		return id, err
+	}
+	return id, str.CloseWrite()
+}
+
+// readUploadHeader reads the id and size an upload starts with.
+func readUploadHeader(str *Stream) (string, int64, error) {
+	r := quicvarint.NewReader(str)
+	idLen, err := quicvarint.Read(r)
+	if err != nil {
+		return "", 0, err
+	}
+	if idLen > maxUploadIDSize {
+		return "", 0, errUploadProtocol
+	}
+	id := make([]byte, idLen)
+	if _, err := io.ReadFull(str, id); err != nil {
+		return "", 0, err
+	}
+	size, err := quicvarint.Read(r)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(id), int64(size), nil
+}
+
+func sendUploadOffset(str *Stream, offset int64) error {
+	if _, err := str.WriteQuic(quicvarint.Append(nil, uint64(offset)), false); err != nil {
+		return err
+	}
+	return str.Flush()
+}
+
+func syncUpload(w io.Writer) error {
+	if s, ok := w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/quicvarint"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryUploadStore keeps uploads in memory, recording where each one was
+// resumed.
+type memoryUploadStore struct {
+	lock    sync.Mutex
+	data    map[string]*bytes.Buffer
+	resumed []int64
+	offset  func(stored int64) int64 // if set, replaces the offset reported
+}
+
+func (m *memoryUploadStore) Resume(id string, size int64) (int64, io.Writer, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.data == nil {
+		m.data = make(map[string]*bytes.Buffer)
+	}
+	b, ok := m.data[id]
+	if !ok {
+		b = &bytes.Buffer{}
+		m.data[id] = b
+	}
+	offset := int64(b.Len())
+	if m.offset != nil {
+		offset = m.offset(offset)
+	}
+	m.resumed = append(m.resumed, offset)
+	return offset, &memoryUploadWriter{m, b}, nil
+}
+
+func (m *memoryUploadStore) stored(id string) []byte {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return bytes.Clone(m.data[id].Bytes())
+}
+
+type memoryUploadWriter struct {
+	m *memoryUploadStore
+	b *bytes.Buffer
+}
+
+func (w *memoryUploadWriter) Write(p []byte) (int, error) {
+	w.m.lock.Lock()
+	defer w.m.lock.Unlock()
+	return w.b.Write(p)
+}
+
+// serveUploads runs ReceiveUpload on every stream of every session accepted
+// by a new listener, reporting the results. It returns the listener's
+// address.
+func serveUploads(t *testing.T, config *Config, store UploadStore, results chan<- error) string {
+	t.Helper()
+
+	l, err := Listen("127.0.0.1:0", config)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			s, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				for {
+					str, err := s.AcceptStream()
+					if err != nil || str == nil {
+						return
+					}
+					_, err = ReceiveUpload(str, store)
+					results <- err
+				}
+			}()
+		}
+	}()
+	return l.l.Addr().String()
+}
+
+func TestUpload_Resume(t *testing.T) {
+	const size = 3<<20 + 12345
+
+	config := newTestConfig(t)
+	store := &memoryUploadStore{}
+	results := make(chan error, 4)
+	addr := serveUploads(t, config, store, results)
+
+	src := make([]byte, size)
+	_, _ = rand.Read(src)
+
+	var (
+		lock      sync.Mutex
+		sessions  []*Session
+		confirmed int64 // the last checkpoint of the first session
+		dropped   bool
+	)
+	t.Cleanup(func() {
+		for _, s := range sessions {
+			_ = s.Close()
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := Upload(ctx, "media", bytes.NewReader(src), size, UploadOptions{
+		Dial: func(ctx context.Context) (*Session, error) {
+			s, err := DialContext(ctx, addr, config)
+			if err == nil {
+				lock.Lock()
+				sessions = append(sessions, s)
+				lock.Unlock()
+			}
+			return s, err
+		},
+		Checkpoint: func(offset int64) {
+			lock.Lock()
+			defer lock.Unlock()
+			// Lose the first session once the server stored a megabyte.
+			if len(sessions) == 1 && offset >= 1<<20 && !dropped {
+				confirmed, dropped = offset, true
+				_ = sessions[0].Close()
+			}
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	// One attempt was interrupted, the other one completed.
+	var failed int
+	for i := 0; i < 2; i++ {
+		if <-results != nil {
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, src, store.stored("media"))
+
+	// The upload resumed past the data confirmed on the first session, so
+	// the stored data is complete without any of it being sent twice.
+	if assert.Len(t, store.resumed, 2) {
+		assert.Equal(t, int64(0), store.resumed[0])
+		assert.GreaterOrEqual(t, store.resumed[1], confirmed)
+	}
+}
+
+func TestUpload_InvalidResumeOffset(t *testing.T) {
+	const size = 1024
+
+	testCases := []struct {
+		name string
+		// serve answers the upload request read from str
+		serve func(str *Stream) error
+		// code is the error code the client's stream is reset with, if any
+		code quic.StreamErrorCode
+		err  error
+	}{
+		{
+			name: "Store",
+			serve: func(str *Stream) error {
+				store := &memoryUploadStore{offset: func(int64) int64 { return size + 1 }}
+				_, err := ReceiveUpload(str, store)
+				return err
+			},
+			code: errorCodeInternal,
+		},
+		{
+			name: "Peer",
+			serve: func(str *Stream) error {
+				if _, _, err := readUploadHeader(str); err != nil {
+					return err
+				}
+				_, err := str.WriteQuic(quicvarint.Append(nil, size+1), false)
+				return err
+			},
+			err: errUploadProtocol,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server, client := newTestSessions(t, newTestConfig(t), newTestConfig(t))
+			served := make(chan error, 1)
+			go func() {
+				str, err := server.AcceptStream()
+				if err != nil || str == nil {
+					served <- err
+					return
+				}
+				served <- tc.serve(str)
+			}()
+
+			dials := 0
+			err := Upload(context.Background(), "media", bytes.NewReader(make([]byte, size)), size, UploadOptions{
+				Dial: func(context.Context) (*Session, error) {
+					dials++
+					return client, nil
+				},
+			})
+			assert.Equal(t, 1, dials, "the upload is not resumed")
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.NoError(t, <-served)
+				return
+			}
+			var streamErr *quic.StreamError
+			if assert.ErrorAs(t, err, &streamErr) {
+				assert.Equal(t, tc.code, streamErr.ErrorCode)
+				assert.True(t, streamErr.Remote)
+			}
+			assert.ErrorIs(t, <-served, errUploadStoreOffset)
+		})
+	}
+}
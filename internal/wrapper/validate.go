@@ -0,0 +1,58 @@
+package wrapper
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// ErrKeyMismatch is returned by Config.Validate for a private key that
+// does not belong to its certificate.
+var ErrKeyMismatch = errors.New("quic: private key does not match certificate")
+
+var errMissingPrivateKey = errors.New("quic: certificate without private key")
+
+// Validate checks that the private keys of the configuration belong to
+// their certificates, which crypto/tls would otherwise only notice during
+// a handshake. RSA, ECDSA and Ed25519 keys are supported. Validate is
+// called when sessions and listeners are created.
+func (c *Config) Validate() error {
+	if c.Certificate != nil {
+		if err := checkKeyPair(c.Certificate, c.PrivateKey); err != nil {
+			return err
+		}
+	}
+	for i, cert := range c.Certificates {
+		if len(cert.Certificate) == 0 {
+			continue // resolved by GetCertificate
+		}
+		leaf := cert.Leaf
+		if leaf == nil {
+			var err error
+			if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+				return fmt.Errorf("quic: certificate %d: %w", i, err)
+			}
+		}
+		if err := checkKeyPair(leaf, cert.PrivateKey); err != nil {
+			return fmt.Errorf("quic: certificate %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// checkKeyPair checks that key is the private key of cert.
+func checkKeyPair(cert *x509.Certificate, key crypto.PrivateKey) error {
+	if key == nil {
+		return errMissingPrivateKey
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("quic: unsupported private key type %T", key)
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.PublicKey) {
+		return ErrKeyMismatch
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+//go:build !js
+// +build !js
+
+package wrapper
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	newKey := map[string]func() (crypto.Signer, error){
+		"RSA": func() (crypto.Signer, error) {
+			return rsa.GenerateKey(rand.Reader, 2048)
+		},
+		"ECDSA": func() (crypto.Signer, error) {
+			return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		},
+		"Ed25519": func() (crypto.Signer, error) {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			return key, err
+		},
+	}
+
+	for name, generate := range newKey {
+		generate := generate
+		t.Run(name, func(t *testing.T) {
+			key, err := generate()
+			assert.NoError(t, err)
+			otherKey, err := generate()
+			assert.NoError(t, err)
+
+			tlsCert := newTestCertificate(t, key)
+			cert, err := x509.ParseCertificate(tlsCert.Certificate[0])
+			assert.NoError(t, err)
+
+			testCases := []struct {
+				name   string
+				config *Config
+				err    error
+			}{
+				{"Matched", &Config{Certificate: cert, PrivateKey: key}, nil},
+				{"Mismatched", &Config{Certificate: cert, PrivateKey: otherKey}, ErrKeyMismatch},
+				{"MissingKey", &Config{Certificate: cert}, errMissingPrivateKey},
+				{"CertificatesMatched", &Config{Certificates: []tls.Certificate{tlsCert}}, nil},
+				{"CertificatesMismatched", &Config{Certificates: []tls.Certificate{
+					{Certificate: tlsCert.Certificate, PrivateKey: otherKey},
+				}}, ErrKeyMismatch},
+			}
+
+			for _, tc := range testCases {
+				tc := tc
+				t.Run(tc.name, func(t *testing.T) {
+					err := tc.config.Validate()
+					if tc.err == nil {
+						assert.NoError(t, err)
+					} else {
+						assert.ErrorIs(t, err, tc.err)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_KeyTypeMismatch(t *testing.T) {
+	ecdsaCert, rsaCert := newDualCertificates(t)
+	cert, err := x509.ParseCertificate(ecdsaCert.Certificate[0])
+	assert.NoError(t, err)
+
+	err = (&Config{Certificate: cert, PrivateKey: rsaCert.PrivateKey}).Validate()
+	assert.ErrorIs(t, err, ErrKeyMismatch)
+}
+
+func TestListen_InvalidConfig(t *testing.T) {
+	config := newTestConfig(t)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	config.PrivateKey = otherKey
+
+	_, err = Listen("127.0.0.1:0", config)
+	assert.ErrorIs(t, err, ErrKeyMismatch)
+}
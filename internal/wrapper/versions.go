@@ -0,0 +1,49 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// ErrVersionNegotiationFailed is matched by the error returned when a dial
+// fails because the server supports none of the QUIC versions offered.
+var ErrVersionNegotiationFailed = errors.New("quic: version negotiation failed")
+
+// VersionNegotiationError is returned by a dial that failed version
+// negotiation. A client can retry with one of the server's versions
+// configured through Config.QUICConfigModifier.
+type VersionNegotiationError struct {
+	// Offered are the versions the client offered.
+	Offered []quic.Version
+	// Supported are the versions the server listed in its Version
+	// Negotiation packet.
+	Supported []quic.Version
+
+	err error
+}
+
+func (e *VersionNegotiationError) Error() string {
+	return fmt.Sprintf("quic: version negotiation failed (offered %v, server supports %v)", e.Offered, e.Supported)
+}
+
+// Is makes errors.Is match ErrVersionNegotiationFailed.
+func (e *VersionNegotiationError) Is(target error) bool {
+	return target == ErrVersionNegotiationFailed
+}
+
+// Unwrap returns the error reported by quic-go.
+func (e *VersionNegotiationError) Unwrap() error {
+	return e.err
+}
+
+// versionNegotiationError converts quic-go's version negotiation failure
+// into a VersionNegotiationError and returns other errors unchanged.
+func versionNegotiationError(err error) error {
+	var vnErr *quic.VersionNegotiationError
+	if !errors.As(err, &vnErr) {
+		return err
+	}
+	return &VersionNegotiationError{Offered: vnErr.Ours, Supported: vnErr.Theirs, err: err}
+}
@@ -1,33 +1,227 @@
 package wrapper
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	quic "github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/logging"
 )
 
 // WritableStream represents a wrapped quic-go SendStream
 type WritableStream struct {
-	s *quic.SendStream
+	s   *quic.SendStream
+	dst io.Writer // s, or a writer scheduling the writes to it
+	w   *bufio.Writer
+
+	wlock sync.Mutex // guards w, which writes and Flush may use concurrently
+
+	lock     sync.Mutex
+	writable chan struct{}
+	ended    bool
+
+	totals *byteTotals // of the session, if any
+
+	written atomic.Uint64 // bytes accepted by write
+	tracer  *tracer
+	acks    *streamAcks     // nil if acknowledgements are not tracked
+	session context.Context // of the session, set with acks
+
+	progress *sendProgress // nil unless opened through a Session
+
+	resetOnClose bool
 }
 
 // Write implements the Conn Write method.
 func (s *WritableStream) Write(p []byte, fin bool) (int, error) {
-	return s.s.Write(p)
+	return s.write(p)
 }
 
-// WriteQuic writes a frame and closes the stream if fin is true
+// WriteQuic writes a frame and closes the stream if fin is true. An empty
+// p with fin only finishes the stream; the peer then reads zero bytes and
+// io.EOF.
 func (s *WritableStream) WriteQuic(p []byte, fin bool) (int, error) {
-	n, err := s.s.Write(p)
+	n, err := s.write(p)
 	if err != nil {
 		return n, err
 	}
 	if fin {
-		return n, s.s.Close()
+		return n, s.finish()
 	}
 	return n, nil
 }
 
+func newWritableStream(str *quic.SendStream, opts StreamOptions) *WritableStream {
+	s := &WritableStream{s: str, dst: str, resetOnClose: opts.ResetOnClose}
+	if opts.SendBufferSize > 0 {
+		s.w = bufio.NewWriterSize(str, clampStreamBufferSize(opts.SendBufferSize))
+	}
+	return s
+}
+
+func (s *WritableStream) write(p []byte) (n int, err error) {
+	s.progress.begin()
+	defer s.progress.end()
+	if s.w != nil {
+		s.wlock.Lock()
+		n, err = s.w.Write(p)
+		s.wlock.Unlock()
+	} else {
+		n, err = s.dst.Write(p)
+	}
+	s.totals.addSent(n)
+	s.written.Add(uint64(n))
+	s.signalWritable()
+	return n, err
+}
+
+// Flush writes any buffered data to the underlying stream.
+// It is a no-op if the stream was opened without a send buffer.
+func (s *WritableStream) Flush() error {
+	if s.w == nil {
+		return nil
+	}
+	s.wlock.Lock()
+	defer s.wlock.Unlock()
+	return s.flushLocked()
+}
+
+// flushLocked writes the buffered data to quic-go. The write lock must be
+// held.
+func (s *WritableStream) flushLocked() error {
+	s.progress.begin()
+	defer s.progress.end()
+	return s.w.Flush()
+}
+
+// Buffered returns the number of bytes held in the stream's send buffer,
+// i.e. written but not yet handed to quic-go. Data handed to quic-go is
+// not included: quic-go packs it as flow and congestion control allow
+// without reporting its progress. The result is therefore a lower bound
+// of the unsent data, and always zero without a send buffer. It waits for
+// a write in progress.
+func (s *WritableStream) Buffered() int {
+	if s.w == nil {
+		return 0
+	}
+	s.wlock.Lock()
+	defer s.wlock.Unlock()
+	return s.w.Buffered()
+}
+
+// StreamResetError is returned when the wrapper aborted a write by
+// resetting the stream.
+type StreamResetError struct {
+	// Code is the error code the stream was reset with.
+	Code uint16
+	// Err is the error that caused the reset.
+	Err error
+}
+
+func (e *StreamResetError) Error() string {
+	return fmt.Sprintf("quic: stream reset with code %d: %v", e.Code, e.Err)
+}
+
+// Unwrap returns the error that caused the reset.
+func (e *StreamResetError) Unwrap() error {
+	return e.Err
+}
+
+// WriteWithResetOnTimeout writes p, giving up after d. Unlike a plain write
+// deadline, which leaves the stream half-written, a timeout resets the
+// stream with code so the peer learns the data is incomplete. The returned
+// *StreamResetError carries the code. Any write deadline set before is
+// cleared.
+func (s *WritableStream) WriteWithResetOnTimeout(p []byte, d time.Duration, code uint16) (int, error) {
+	if err := s.SetWriteDeadline(time.Now().Add(d)); err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = s.SetWriteDeadline(time.Time{})
+	}()
+
+	n, err := s.write(p)
+	if err != nil && isTimeout(err) {
+		s.s.CancelWrite(quic.StreamErrorCode(code))
+		return n, &StreamResetError{Code: code, Err: err}
+	}
+	return n, err
+}
+
+// Writable returns a channel that holds a value while the peer's flow
+// control leaves the stream room for more data, so a Write fitting into
+// that room does not block on flow control. The room is taken from the
+// MAX_STREAM_DATA and MAX_DATA frames reported by quic-go's packet
+// tracing, as quic-go does not expose it: the value is taken away once the
+// data written, including data held in the send buffer, reaches the
+// stream's limit or quic-go announced being blocked by the connection's
+// limit, and put back when the peer raises the limit as it reads.
+// Producers can select on the channel instead of blocking in Write;
+// congestion control may still delay a Write briefly, and a Write larger
+// than the room blocks for the rest of its data.
+// The channel is closed when the write side of the stream ends.
+func (s *WritableStream) Writable() <-chan struct{} {
+	s.lock.Lock()
+	if s.writable != nil {
+		defer s.lock.Unlock()
+		return s.writable
+	}
+	s.writable = make(chan struct{}, 1)
+	s.lock.Unlock()
+
+	if s.progress != nil {
+		check := s.signalWritable
+		s.progress.onCredit.Store(&check)
+	}
+	go func() {
+		<-s.s.Context().Done()
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.ended = true
+		close(s.writable)
+	}()
+	s.signalWritable()
+	return s.writable
+}
+
+// signalWritable puts the value into the Writable channel if the stream
+// has flow control credit left, and takes it away otherwise.
+func (s *WritableStream) signalWritable() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.writable == nil || s.ended {
+		return
+	}
+	if s.hasCredit() {
+		select {
+		case s.writable <- struct{}{}:
+		default:
+		}
+	} else {
+		select {
+		case <-s.writable:
+		default:
+		}
+	}
+}
+
+// hasCredit reports whether the peer's flow control allows more data to be
+// sent on the stream. Streams not opened through a Session always do.
+func (s *WritableStream) hasCredit() bool {
+	if s.tracer == nil || s.progress == nil {
+		return true
+	}
+	limit, connBlocked := s.tracer.uniStreamCredit(s.progress)
+	return !connBlocked && logging.ByteCount(s.written.Load()) < limit
+}
+
 // StreamID returns the ID of the QuicStream
 func (s *WritableStream) StreamID() uint64 {
 	return uint64(s.s.StreamID())
@@ -35,12 +229,32 @@ func (s *WritableStream) StreamID() uint64 {
 
 // Close implements the Conn Close method. It is used to close
 // the connection. Any calls to Write will be unblocked and return an error.
+// Buffered data is flushed before the stream is finished, unless the
+// stream was opened with StreamOptions.ResetOnClose.
 func (s *WritableStream) Close() error {
+	if s.resetOnClose {
+		s.s.CancelWrite(errorCodeCanceled)
+		return nil
+	}
+	return s.finish()
+}
+
+// finish flushes buffered data and finishes the stream.
+func (s *WritableStream) finish() error {
+	if s.w == nil {
+		return s.s.Close()
+	}
+	s.wlock.Lock()
+	defer s.wlock.Unlock()
+	if err := s.flushLocked(); err != nil {
+		return err
+	}
 	return s.s.Close()
 }
 
 // SetWriteDeadline sets the deadline for future Write calls. A zero value for t means Write will not time out.
 func (s *WritableStream) SetWriteDeadline(t time.Time) error {
+	setWriteDeadline(s.dst, t)
 	return s.s.SetWriteDeadline(t)
 }
 
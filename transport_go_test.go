@@ -6,8 +6,10 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/binary"
@@ -253,3 +255,58 @@ func GenerateSelfSigned() (*x509.Certificate, crypto.PrivateKey, error) {
 
 	return cert, priv, nil
 }
+
+// GenerateSelfSignedDeterministic creates a self-signed certificate for host
+// that only depends on seed, so tests get the same certificate on every run.
+// The key is derived from the seed: this is insecure and for tests only.
+//
+// Ed25519 is used as its key generation and signatures, unlike ECDSA in the
+// standard library, do not consume randomness.
+func GenerateSelfSignedDeterministic(seed []byte, host string) (*x509.Certificate, crypto.PrivateKey, error) {
+	keySeed := sha256.Sum256(seed)
+	priv := ed25519.NewKeyFromSeed(keySeed[:])
+
+	serial := sha256.Sum256(keySeed[:])
+	serialNumber := new(big.Int).SetBytes(serial[:16])
+
+	template := x509.Certificate{
+		ExtKeyUsage: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageClientAuth,
+			x509.ExtKeyUsageServerAuth,
+		},
+		BasicConstraintsValid: true,
+		NotBefore:             time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		NotAfter:              time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC),
+		SerialNumber:          serialNumber,
+		Version:               2,
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		IsCA:                  true,
+	}
+
+	raw, err := x509.CreateCertificate(nil, &template, &template, priv.Public(), priv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, priv, nil
+}
+
+func TestGenerateSelfSignedDeterministic(t *testing.T) {
+	certA, keyA, err := GenerateSelfSignedDeterministic([]byte("seed"), "localhost")
+	assert.NoError(t, err)
+	certB, keyB, err := GenerateSelfSignedDeterministic([]byte("seed"), "localhost")
+	assert.NoError(t, err)
+	assert.Equal(t, certA.Raw, certB.Raw)
+	assert.Equal(t, keyA, keyB)
+
+	certC, _, err := GenerateSelfSignedDeterministic([]byte("other seed"), "localhost")
+	assert.NoError(t, err)
+	assert.NotEqual(t, certA.Raw, certC.Raw)
+}
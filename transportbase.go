@@ -2,13 +2,16 @@ package quic
 
 import (
 	"crypto"
+	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/pion/logging"
 	"github.com/pinjiang/quic/internal/wrapper"
+	quic "github.com/quic-go/quic-go"
 )
 
 // TransportBase is the base for Transport. Most of the
@@ -28,6 +31,28 @@ type Config struct {
 	Certificate   *x509.Certificate
 	PrivateKey    crypto.PrivateKey
 	LoggerFactory logging.LoggerFactory
+
+	// TLSConfigModifier, if set, is called with the TLS configuration
+	// right before it is used. It can change any field, including the
+	// certificate verification that is skipped by default.
+	TLSConfigModifier func(*tls.Config)
+
+	// QUICConfigModifier, if set, is called with the quic-go configuration
+	// right before it is used. It runs after the defaults are applied and
+	// can override any of them.
+	QUICConfigModifier func(*quic.Config)
+
+	// Preface, if set, is written by the client at the start of the
+	// first bidirectional stream it creates.
+	Preface []byte
+
+	// StreamIdleTimeout, if non-zero, resets bidirectional streams without
+	// reads or writes for this long, using stream error code 2.
+	StreamIdleTimeout time.Duration
+
+	// LocalAddr, if set, is the local address NewTransport binds its
+	// socket to. By default the system picks an ephemeral port.
+	LocalAddr *net.UDPAddr
 }
 
 // StartBase is used to start the TransportBase. Most implementations
@@ -76,8 +101,13 @@ func (b *TransportBase) startBase(s *wrapper.Session) error {
 
 func (c *Config) clone() *wrapper.Config {
 	return &wrapper.Config{
-		Certificate: c.Certificate,
-		PrivateKey:  c.PrivateKey,
+		Certificate:        c.Certificate,
+		PrivateKey:         c.PrivateKey,
+		TLSConfigModifier:  c.TLSConfigModifier,
+		QUICConfigModifier: c.QUICConfigModifier,
+		Preface:            c.Preface,
+		StreamIdleTimeout:  c.StreamIdleTimeout,
+		LocalAddr:          c.LocalAddr,
 	}
 }
 
@@ -148,12 +178,12 @@ func (b *TransportBase) acceptStreams() {
 	for {
 		s, err := b.session.AcceptStream()
 		if err != nil {
-			b.log.Errorf("Failed to accept stream: %v", err)
+			b.errorf("Failed to accept stream: %v", err)
 			stopErr := b.Stop(TransportStopInfo{
 				Reason: err.Error(),
 			})
 			if stopErr != nil {
-				b.log.Errorf("Failed to stop transport: %v", stopErr)
+				b.errorf("Failed to stop transport: %v", stopErr)
 			}
 			return
 		}
@@ -170,12 +200,12 @@ func (b *TransportBase) acceptUniStreams() {
 	for {
 		s, err := b.session.AcceptUniStream()
 		if err != nil {
-			b.log.Errorf("Failed to accept stream: %v", err)
+			b.errorf("Failed to accept stream: %v", err)
 			stopErr := b.Stop(TransportStopInfo{
 				Reason: err.Error(),
 			})
 			if stopErr != nil {
-				b.log.Errorf("Failed to stop transport: %v", stopErr)
+				b.errorf("Failed to stop transport: %v", stopErr)
 			}
 			return
 		}
@@ -188,6 +218,26 @@ func (b *TransportBase) acceptUniStreams() {
 	}
 }
 
+// SetName labels the connection, e.g. with the ID of the user it belongs
+// to. The name prefixes the log messages of the connection. The transport
+// must have been started.
+func (b *TransportBase) SetName(name string) {
+	b.session.SetName(name)
+}
+
+// Name returns the name set by SetName.
+func (b *TransportBase) Name() string {
+	return b.session.Name()
+}
+
+// errorf logs an error of the connection, prefixed with its name.
+func (b *TransportBase) errorf(format string, args ...interface{}) {
+	if name := b.session.Name(); name != "" {
+		format = "[" + name + "] " + format
+	}
+	b.log.Errorf(format, args...)
+}
+
 // Stop stops and closes the TransportBase.
 func (b *TransportBase) Stop(stopInfo TransportStopInfo) error {
 	b.lock.Lock()
@@ -24,6 +24,13 @@ func (s *WritableStream) StreamID() uint64 {
 	return s.s.StreamID()
 }
 
+// Writable returns a channel that receives a value whenever the stream can
+// take more data without blocking on flow control. It is closed once the
+// stream ends.
+func (s *WritableStream) Writable() <-chan struct{} {
+	return s.s.Writable()
+}
+
 // SetWriteDeadline sets the deadline for future Write calls. A zero value for t means Write will not time out.
 func (s *WritableStream) SetWriteDeadline(t time.Time) error {
 	return s.s.SetWriteDeadline(t)